@@ -1,6 +1,8 @@
 package builder
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"strings"
 	"sync"
@@ -36,6 +38,10 @@ type Builder struct {
 	Components       map[string][]map[string]string // compile Components
 	SelectComponents []string                       // just for compile Component in order
 	UseWrite         bool                           // Whether use write DB for select.
+	statement        string                         // The statement compiled by ToSQL, select by default.
+	ctx              context.Context                // The context of the query.
+	attempts         int                            // The attempts made to run the query.
+	pool             string                         // The pool which ran the query, PoolRead or PoolWrite.
 	mu               sync.Mutex
 	debug            bool
 }
@@ -82,6 +88,10 @@ func (b *Builder) Reset() {
 	b.Operators = map[string]interface{}{}
 	b.Components = map[string][]map[string]string{}
 	b.UseWrite = false
+	b.statement = ""
+	b.ctx = nil
+	b.attempts = 0
+	b.pool = ""
 	b.debug = false
 	b.mu.Unlock()
 }
@@ -95,6 +105,21 @@ func (b *Builder) Debug(bo ...bool) *Builder {
 	return b
 }
 
+// WithContext Set the context of the query, the context is used to run
+// the statement and to track the sticky request scope.
+func (b *Builder) WithContext(ctx context.Context) *Builder {
+	b.ctx = ctx
+	return b
+}
+
+// context Get the context of the query.
+func (b *Builder) context() context.Context {
+	if b.ctx == nil {
+		return context.Background()
+	}
+	return b.ctx
+}
+
 // Insert new record into the database. CURD [C]
 func (b *Builder) Insert(values []map[string]string) int64 {
 	b.Values = values
@@ -141,13 +166,93 @@ func (b *Builder) Exists() bool {
 }
 
 // RunSelect Run the query as a "select" statement against the connection.CURD [R]
-func (b *Builder) RunSelect() []map[string]interface{} { return b.Connection.Select(true) }
+func (b *Builder) RunSelect() []map[string]interface{} { return b.Connection.Select(!b.UseWrite) }
 
 // Delete a record from the database. CURD [D]
 func (b *Builder) Delete() int64 { return b.Connection.Delete() }
 
+// AsInsert Make ToSQL compile an insert of the values.
+func (b *Builder) AsInsert(values []map[string]string) *Builder {
+	b.statement = "insert"
+	b.Values = values
+	return b
+}
+
+// AsUpdate Make ToSQL compile an update of the value.
+func (b *Builder) AsUpdate(value map[string]string) *Builder {
+	b.statement = "update"
+	b.Values = append(b.Values, value)
+	return b
+}
+
+// AsDelete Make ToSQL compile a delete.
+func (b *Builder) AsDelete() *Builder {
+	b.statement = "delete"
+	return b
+}
+
+// ToSQL Get the SQL and the bindings of the query without running it. The
+// query is a select, unless AsInsert, AsUpdate or AsDelete is called:
+//
+//	sql, bindings, err := DB.Table("users").Where("id", "1").AsUpdate(value).ToSQL()
+//
+// The Builder is reset, like after running the query.
+func (b *Builder) ToSQL() (sql string, bindings []interface{}, err error) {
+	// without a table the Builder was not locked by From, nor to be reset
+	if b.FromTable == "" {
+		return "", nil, errors.New("builder: ToSQL needs a table")
+	}
+	defer b.Reset()
+
+	conn, ok := b.Connection.(interface{ base() *Connection })
+	if !ok {
+		return "", nil, errors.New("builder: ToSQL needs a builder connection")
+	}
+	grammar := conn.base().Grammar
+
+	b.PArgs = nil
+	switch b.statement {
+	case "insert":
+		if len(b.Values) == 0 || len(b.Values[0]) == 0 {
+			return "", nil, errors.New("builder: insert needs values")
+		}
+		grammar.CompileInsert()
+	case "update":
+		if len(b.Values) == 0 || len(b.Values[0]) == 0 {
+			return "", nil, errors.New("builder: update needs values")
+		}
+		grammar.CompileUpdate()
+	case "delete":
+		grammar.CompileDelete()
+	default:
+		if _, ok := b.Components["columns"]; !ok {
+			b.Columns = []string{"*"}
+			b.Components["columns"] = nil
+		}
+		grammar.CompileSelect()
+	}
+
+	return b.PSql, b.PArgs, nil
+}
+
+// ToRawSQL Get the SQL of the query with the bindings substituted by their
+// literals, quoted by the grammar of the connection. It is for debugging
+// only: never run the result, the quoting is no substitute for bindings.
+func (b *Builder) ToRawSQL() (string, error) {
+	conn := b.Connection
+
+	sql, bindings, err := b.ToSQL()
+	if err != nil {
+		return "", err
+	}
+	return interpolate(sql, bindings, conn.(interface{ base() *Connection }).base().Grammar.Literal), nil
+}
+
 // UseWriteDB Use the write DB for query.
-func (b *Builder) UseWriteDB() { b.UseWrite = true }
+func (b *Builder) UseWriteDB() *Builder {
+	b.UseWrite = true
+	return b
+}
 
 // Determine if the given operator and value combination is legal.
 func invalidOperatorAndValue(operator, value string) bool {
@@ -0,0 +1,167 @@
+package builder
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// envPattern matches $$, ${VAR}, ${VAR:-default} and ${VAR-default}.
+var envPattern = regexp.MustCompile(`\$\$|\$\{([A-Za-z_][A-Za-z0-9_]*)(?:(:?-)([^}]*))?\}`)
+
+// expandEnv replace the environment variables of s. Like the shell,
+// ${VAR:-default} uses the default when VAR is unset or empty and
+// ${VAR-default} only when VAR is unset. $$ is a literal $.
+func expandEnv(s string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+
+	return envPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$$" {
+			return "$"
+		}
+		sub := envPattern.FindStringSubmatch(match)
+		name, op, def := sub[1], sub[2], sub[3]
+
+		v, ok := os.LookupEnv(name)
+		switch {
+		case op == ":-" && v == "":
+			return def
+		case op == "-" && !ok:
+			return def
+		}
+		return v
+	})
+}
+
+// expandConfigEnv replace the environment variables of every string of
+// the config, so the values are never parsed as YAML.
+func expandConfigEnv(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			expandConfigEnv(v.Elem())
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Field(i).CanSet() {
+				expandConfigEnv(v.Field(i))
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			expandConfigEnv(v.Index(i))
+		}
+	case reflect.String:
+		v.SetString(expandEnv(v.String()))
+	}
+}
+
+// readPasswordFile read a password from a mounted secret, the trailing
+// newline of the file is dropped.
+func readPasswordFile(cName, path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("config %s password_file: %w", cName, err)
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// resolveConfig replace the environment variables and read the password
+// files of the config.
+func resolveConfig(config *DatabaseConfig) error {
+	expandConfigEnv(reflect.ValueOf(config))
+
+	return readPasswordFiles(config)
+}
+
+// readPasswordFiles replace the passwords of the config by the content of
+// their password files.
+func readPasswordFiles(config *DatabaseConfig) error {
+	for _, c := range []struct {
+		name     string
+		file     string
+		password *string
+	}{
+		{"mysql", config.Mysql.PasswordFile, &config.Mysql.Password},
+		{"pgsql", config.Pgsql.PasswordFile, &config.Pgsql.Password},
+	} {
+		if c.file == "" {
+			continue
+		}
+		password, err := readPasswordFile(c.name, c.file)
+		if err != nil {
+			return err
+		}
+		*c.password = password
+	}
+
+	return nil
+}
+
+// configFromEnv build the config of a single connection from the DB_*
+// environment variables, it reports false when DB_CONNECTION is not set.
+//
+//	DB_CONNECTION  mysql, pgsql or sqlite
+//	DB_URL         see ParseURL
+//	DB_HOST, DB_PORT, DB_DATABASE, DB_USERNAME, DB_PASSWORD, DB_PASSWORD_FILE
+//	DB_READ_HOST, DB_WRITE_HOST  comma separated hosts
+//	DB_CHARSET, DB_COLLATION, DB_SOCKET, DB_SSLMODE, DB_PREFIX
+func configFromEnv() (config DatabaseConfig, ok bool) {
+	name := os.Getenv("DB_CONNECTION")
+	if name == "" {
+		return config, false
+	}
+	config.Default = name
+
+	env := func(key string) string { return os.Getenv("DB_" + key) }
+	hosts := func(key string) []string {
+		if env(key) == "" {
+			return nil
+		}
+		return strings.Split(env(key), ",")
+	}
+
+	switch name {
+	case "sqlite":
+		config.SQLite.Driver = "sqlite3"
+		config.SQLite.URL = env("URL")
+		config.SQLite.Database = env("DATABASE")
+		config.SQLite.Prefix = env("PREFIX")
+	case "mysql":
+		config.Mysql.Driver = "mysql"
+		config.Mysql.URL = env("URL")
+		config.Mysql.Read.Host = hosts("READ_HOST")
+		config.Mysql.Write.Host = hosts("WRITE_HOST")
+		config.Mysql.Host = env("HOST")
+		config.Mysql.Port = env("PORT")
+		config.Mysql.Database = env("DATABASE")
+		config.Mysql.Username = env("USERNAME")
+		config.Mysql.Password = env("PASSWORD")
+		config.Mysql.PasswordFile = env("PASSWORD_FILE")
+		config.Mysql.Charset = env("CHARSET")
+		config.Mysql.Collation = env("COLLATION")
+		config.Mysql.UnixSocket = env("SOCKET")
+		config.Mysql.Prefix = env("PREFIX")
+	case "pgsql":
+		config.Pgsql.Driver = "postgres"
+		config.Pgsql.URL = env("URL")
+		config.Pgsql.Read.Host = hosts("READ_HOST")
+		config.Pgsql.Write.Host = hosts("WRITE_HOST")
+		config.Pgsql.Host = env("HOST")
+		config.Pgsql.Port = env("PORT")
+		config.Pgsql.Database = env("DATABASE")
+		config.Pgsql.Username = env("USERNAME")
+		config.Pgsql.Password = env("PASSWORD")
+		config.Pgsql.PasswordFile = env("PASSWORD_FILE")
+		config.Pgsql.Charset = env("CHARSET")
+		config.Pgsql.Sslmode = env("SSLMODE")
+		config.Pgsql.Prefix = env("PREFIX")
+	}
+
+	return config, true
+}
@@ -0,0 +1,82 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("DB_HOST", "db.local")
+	t.Setenv("DB_EMPTY", "")
+	os.Unsetenv("DB_UNSET")
+
+	for s, want := range map[string]string{
+		"${DB_HOST}":             "db.local",
+		"${DB_HOST:-localhost}":  "db.local",
+		"${DB_EMPTY:-localhost}": "localhost",
+		"${DB_EMPTY-localhost}":  "",
+		"${DB_UNSET-localhost}":  "localhost",
+		"${DB_UNSET:-localhost}": "localhost",
+		"${DB_UNSET}":            "",
+		"tcp(${DB_HOST}):3306":   "tcp(db.local):3306",
+		"pa$$${DB_HOST}":         "pa$db.local",
+		"$${DB_HOST}":            "${DB_HOST}",
+		"pa$word":                "pa$word",
+		"no variables":           "no variables",
+	} {
+		if got := builder.ExpandEnv(s); got != want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %q got: %q want: %q", s, got, want)
+		}
+	}
+}
+
+func TestReadPasswordFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(path, []byte(" s3cret \r\n\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the trailing newlines are dropped
+	if got, err := builder.ReadPasswordFile("mysql", path); err != nil || got != " s3cret " {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %q, %v want: %q", got, err, " s3cret ")
+	}
+	if _, err := builder.ReadPasswordFile("mysql", path+".missing"); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m a missing password file must fail")
+	}
+}
+
+func TestConfigFromEnv(t *testing.T) {
+	t.Setenv("DB_CONNECTION", "")
+	if _, ok := builder.ConfigFromEnv(); ok {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m want no config without DB_CONNECTION")
+	}
+
+	t.Setenv("DB_CONNECTION", "pgsql")
+	t.Setenv("DB_HOST", "127.0.0.1")
+	t.Setenv("DB_DATABASE", "gogogo")
+	t.Setenv("DB_USERNAME", "root")
+	t.Setenv("DB_PASSWORD", "pa${ss}")
+	t.Setenv("DB_READ_HOST", "10.0.0.2,10.0.0.3")
+	t.Setenv("DB_SSLMODE", "disable")
+
+	config, ok := builder.ConfigFromEnv()
+	if !ok {
+		t.Fatal("\x1b[91mOops🔥\x1b[39m want a config with DB_CONNECTION")
+	}
+	pgsql := config.Pgsql
+	if config.Default != "pgsql" || pgsql.Driver != "postgres" || pgsql.Host != "127.0.0.1" ||
+		pgsql.Database != "gogogo" || pgsql.Username != "root" || pgsql.Sslmode != "disable" ||
+		len(pgsql.Read.Host) != 2 || pgsql.Read.Host[1] != "10.0.0.3" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v", config)
+	}
+
+	// the values of the environment are not expanded
+	_, DM := builder.Run()
+	defer DM.Close()
+	if got := DM.Connection("").(*builder.PostgresConnection).Config.Password; got != "pa${ss}" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %q want: %q", got, "pa${ss}")
+	}
+}
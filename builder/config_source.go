@@ -0,0 +1,112 @@
+package builder
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// The formats of the config sources.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+	FormatTOML = "toml"
+)
+
+// NewDatabaseManager Make a DatabaseManager from a config built in Go. The
+// ${VAR} of the config are replaced and the password files are read.
+func NewDatabaseManager(config DatabaseConfig) (*DatabaseManager, error) {
+	if err := resolveConfig(&config); err != nil {
+		return nil, err
+	}
+	return newDatabaseManager(config), nil
+}
+
+// newDatabaseManager Make a DatabaseManager from a resolved config.
+func newDatabaseManager(config DatabaseConfig) *DatabaseManager {
+	return &DatabaseManager{
+		ymlConfig:   config,
+		isLoaded:    true,
+		defaultName: config.Default,
+		connections: map[string]Connector{},
+	}
+}
+
+// NewDatabaseManagerFromFile Make a DatabaseManager from a YAML, JSON or
+// TOML config file, the format is chosen by the extension of the file.
+func NewDatabaseManagerFromFile(path string) (*DatabaseManager, error) {
+	config, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dm := newDatabaseManager(config)
+	dm.ymlPath = path
+	return dm, nil
+}
+
+// NewDatabaseManagerFromReader Make a DatabaseManager from a config read
+// from r in the given format, one of FormatYAML, FormatJSON or FormatTOML.
+func NewDatabaseManagerFromReader(r io.Reader, format string) (*DatabaseManager, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := decodeConfig(data, format)
+	if err != nil {
+		return nil, err
+	}
+	return NewDatabaseManager(config)
+}
+
+// configFormat Get the format of a config file by its extension.
+func configFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatYAML
+	}
+}
+
+// decodeConfig decode the config in the given format. The JSON and TOML
+// configs are converted to YAML, so that the keys are the same in every
+// format.
+func decodeConfig(data []byte, format string) (config DatabaseConfig, err error) {
+	var doc interface{}
+	switch format {
+	case FormatYAML, "yml", "":
+	case FormatJSON:
+		if err = json.Unmarshal(data, &doc); err != nil {
+			return config, fmt.Errorf("json config: %v", err)
+		}
+	case FormatTOML:
+		var table map[string]interface{}
+		if _, err = toml.NewDecoder(bytes.NewReader(data)).Decode(&table); err != nil {
+			return config, fmt.Errorf("toml config: %v", err)
+		}
+		doc = table
+	default:
+		return config, fmt.Errorf("config format %q unknown, want one of yaml, json, toml", format)
+	}
+
+	if doc != nil {
+		if data, err = yaml.Marshal(doc); err != nil {
+			return config, fmt.Errorf("%s config: %v", format, err)
+		}
+	}
+
+	if err = yaml.Unmarshal(data, &config); err != nil {
+		return config, fmt.Errorf("yamlFile.Get err: #%v ", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,155 @@
+package builder_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestValidateConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.yml")
+	yml := `
+default: oracle
+mysql:
+  driver: mysql
+  read:
+    host:
+      - 192.168.1.1
+    strategy: fastest
+  host: localhost
+  port: 33o6
+  database: gogogo
+  connect:
+    backoff: 5 seconds
+pgsql:
+  driver: postgre
+  host: 127.0.0.1
+  username: root
+  sslmode: required
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, err := range builder.ValidateConfig(path) {
+		got = append(got, err.Error())
+	}
+
+	want := []string{
+		`config default: unknown connection "oracle"`,
+		`config mysql.connect.backoff: invalid duration "5 seconds"`,
+		`config mysql.port: invalid port "33o6"`,
+		`config mysql.username: missing`,
+		`config mysql.read.strategy: unknown strategy "fastest"`,
+		`config pgsql.driver: unknown driver "postgre", did you mean "postgres"?`,
+		`config pgsql.database: missing`,
+		`config pgsql.sslmode: invalid sslmode "required"`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("\x1b[91mOops🔥\x1b[39m got %d errors, want %d:\n%s", len(got), len(want), strings.Join(got, "\n"))
+	}
+	for i := range want {
+		if !strings.HasPrefix(got[i], want[i]) {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %s\nwant: %s", got[i], want[i])
+		}
+	}
+}
+
+func TestValidateConfigDefault(t *testing.T) {
+	if errs := builder.ValidateConfig(""); len(errs) != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the default config is invalid: %v", errs)
+	}
+}
+
+func TestValidateConfigEmptyHosts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.yml")
+	yml := `
+default: mysql
+mysql:
+  driver: mysql
+  read:
+    host:
+      -
+  write:
+    host:
+      -
+  host: localhost
+  database: gogogo
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// the empty items of the host lists need no username
+	if errs := builder.ValidateConfig(path); len(errs) != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: no error", errs)
+	}
+}
+
+func TestNewDatabaseManagerFromFile(t *testing.T) {
+	sources := map[string]string{
+		"database.json": `{
+	"default": "mysql",
+	"mysql": {
+		"driver": "mysql",
+		"host": "localhost",
+		"port": 3306,
+		"database": "gogogo",
+		"username": "root",
+		"pool": {"max_open_conns": 10, "conn_max_lifetime": "5m"}
+	}
+}`,
+		"database.toml": `
+default = "mysql"
+
+[mysql]
+driver = "mysql"
+host = "localhost"
+port = "3306"
+database = "gogogo"
+username = "root"
+
+[mysql.pool]
+max_open_conns = 10
+conn_max_lifetime = "5m"
+`,
+	}
+
+	for name, source := range sources {
+		path := filepath.Join(t.TempDir(), name)
+		if err := os.WriteFile(path, []byte(source), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		DM, err := builder.NewDatabaseManagerFromFile(path)
+		if err != nil {
+			t.Fatalf("\x1b[91mOops🔥\x1b[39m %s: %v", name, err)
+		}
+
+		write, _ := DM.Connection("").PoolSettings()
+		config := DM.Connection("").(*builder.MysqlConnection).Config
+		if config.Host != "localhost" || config.Port != "3306" || config.Database != "gogogo" ||
+			write.MaxOpenConns != 10 || write.ConnMaxLifetime != 5*time.Minute {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s: got: %+v", name, config)
+		}
+	}
+}
+
+func TestNewDatabaseManagerFromReader(t *testing.T) {
+	DM, err := builder.NewDatabaseManagerFromReader(strings.NewReader(`{"default": "sqlite", "sqlite": {"driver": "sqlite3", "database": ":memory:"}}`), builder.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DM.Connection("").(*builder.SQLiteConnection).Config.Database; got != ":memory:" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %s want: :memory:", got)
+	}
+
+	if _, err = builder.NewDatabaseManagerFromReader(strings.NewReader(""), "ini"); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m an unknown format must fail")
+	}
+}
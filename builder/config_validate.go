@@ -0,0 +1,164 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConfigError a problem of one field of the database config.
+type ConfigError struct {
+	Connection string // The name of the connection, e.g. "mysql".
+	Field      string // The path of the field, e.g. "read.strategy".
+	Message    string
+}
+
+// Error implements error.
+func (e *ConfigError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("config %s: %s", e.Connection, e.Message)
+	}
+	return fmt.Sprintf("config %s.%s: %s", e.Connection, e.Field, e.Message)
+}
+
+// connectionDrivers the driver of each of the connections of the yml file.
+var connectionDrivers = map[string]string{
+	"mysql":  "mysql",
+	"pgsql":  "postgres",
+	"sqlite": "sqlite3",
+}
+
+// sslmodes the sslmode values known by lib/pq.
+var sslmodes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// journalModes the journal_mode values of sqlite.
+var journalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// ValidateConfig Check the config file before any connection is attempted
+// and return every problem found, each one a *ConfigError naming the
+// connection and the field. An empty path checks the config Run() would
+// load. A nil result means the config is valid.
+func ValidateConfig(path string) []error {
+	yml, err := readConfig(path)
+	if err != nil {
+		return []error{err}
+	}
+
+	dm := &DatabaseManager{ymlConfig: yml, isLoaded: true}
+	names := dm.configuredConnections()
+
+	var errs []error
+	if yml.Default != "" {
+		if _, ok := connectionDrivers[yml.Default]; !ok {
+			errs = append(errs, &ConfigError{Connection: "default", Message: fmt.Sprintf("unknown connection %q, want one of mysql, pgsql, sqlite", yml.Default)})
+		} else if !contains(names, yml.Default) {
+			errs = append(errs, &ConfigError{Connection: "default", Message: fmt.Sprintf("connection %q is not configured", yml.Default)})
+		}
+	}
+
+	for _, name := range names {
+		config, err := dm.parseConfig(name)
+		errs = append(errs, unjoin(err)...)
+		errs = append(errs, unjoin(validateDBConfig(name, config))...)
+	}
+	return errs
+}
+
+// validateDBConfig check the fields of a parsed connection config.
+func validateDBConfig(cName string, config DBConfig) error {
+	p := &configParser{name: cName}
+
+	want := connectionDrivers[cName]
+	switch {
+	case config.Driver == "":
+		p.fail("driver", "missing, want %q", want)
+	case config.Driver != want:
+		if urlSchemes[config.Driver] == want || strings.HasPrefix(want, config.Driver) {
+			p.fail("driver", "unknown driver %q, did you mean %q?", config.Driver, want)
+		} else {
+			p.fail("driver", "unknown driver %q, want %q", config.Driver, want)
+		}
+	}
+	if _, err := sqlDriverName(config.Driver); err != nil && config.Driver == want {
+		p.fail("driver", "%v", err)
+	}
+
+	if config.Database == "" {
+		p.fail("database", "missing")
+	}
+
+	validateTLS(p, config)
+
+	if config.Driver == "sqlite3" {
+		if config.JournalMode != "" && !contains(journalModes, strings.ToUpper(config.JournalMode)) {
+			p.fail("journal_mode", "invalid journal_mode %q, want one of %v", config.JournalMode, journalModes)
+		}
+		return errors.Join(p.errs...)
+	}
+
+	if config.Host == "" && config.UnixSocket == "" && countHosts(config.WriteHost) == 0 {
+		p.fail("host", "missing, set a host, a write host or a unix_socket")
+	}
+	if config.Port != "" {
+		if port, err := strconv.Atoi(config.Port); err != nil || port < 1 || port > 65535 {
+			p.fail("port", "invalid port %q", config.Port)
+		}
+	}
+	if config.Username == "" && (countHosts(config.ReadHost) > 0 || countHosts(config.WriteHost) > 0) {
+		p.fail("username", "missing, the read and write hosts need credentials")
+	}
+
+	if config.Sslmode != "" && !contains(sslmodes, config.Sslmode) {
+		p.fail("sslmode", "invalid sslmode %q, want one of %v", config.Sslmode, sslmodes)
+	}
+	if config.Loc != "" {
+		if _, err := time.LoadLocation(config.Loc); err != nil {
+			p.fail("loc", "unknown location %q", config.Loc)
+		}
+	}
+	if !supportedBalancers(config.ReadStrategy) {
+		p.fail("read.strategy", "unknown strategy %q, want one of %s, %s, %s", config.ReadStrategy, BalanceRandom, BalanceRoundRobin, BalanceLeastInUse)
+	}
+	if config.ConnectRetries < 0 {
+		p.fail("connect.retries", "must not be negative")
+	}
+	if config.Retry.MaxAttempts < 0 {
+		p.fail("retry.max_attempts", "must not be negative")
+	}
+
+	return errors.Join(p.errs...)
+}
+
+// countHosts count the hosts which are set, an empty item of the yml list
+// like "host: [ ]" is not a host.
+func countHosts(hosts []string) (n int) {
+	for _, host := range hosts {
+		if host != "" {
+			n++
+		}
+	}
+	return
+}
+
+// unjoin split an error made by errors.Join.
+func unjoin(err error) []error {
+	if err == nil {
+		return nil
+	}
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+	return []error{err}
+}
+
+// contains reports whether the value is in the list.
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
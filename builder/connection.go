@@ -1,89 +1,247 @@
 package builder
 
 import (
+	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
-
-	_ "github.com/go-sql-driver/mysql"
-	_ "github.com/lib/pq"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 // Connection default DB connection
 type Connection struct {
-	DB                *sql.DB
-	DBRead            *sql.DB                  // The DB for read.
-	Config            DBConfig                 // The database connection configuration options.
-	Grammar           Grammar                  // The query grammar implementation.
-	queryLog          []map[string]interface{} // All of the queries run against the connection.
-	loggingQueries    bool                     // Indicates whether queries are being logged.
-	recordsIsModified bool                     // Indicates if changes have been made to the database.
-	Pretending        bool                     // Indicates if the connection is in a "dry run".
+	DB             *sql.DB
+	DBRead         *sql.DB  // The DB of the first read replica.
+	Config         DBConfig // The database connection configuration options.
+	Grammar        Grammar  // The query grammar implementation.
+	queryLog       queryLog // The last queries run against the connection.
+	loggingQueries bool     // Indicates whether queries are being logged.
+	logMu          sync.Mutex
+	Pretending     bool // Indicates if the connection is in a "dry run".
+	stateMu        sync.Mutex
+	closing        bool           // Indicates the connection no longer accepts queries.
+	inFlight       sync.WaitGroup // The queries that are running against the connection.
+	replicas       []*hostDB      // The read replicas.
+	primaries      []*hostDB      // The write hosts, in the order of failover.
+	primary        int            // The index of the write host in use.
+	failoverMu     sync.Mutex
+	failoverHooks  []func(from, to string, err error)
+	connectMu      sync.Mutex
+	connected      bool          // Indicates if the database has been dialed.
+	readNext       uint64        // The round-robin cursor of the read replicas.
+	stopCheck      chan struct{} // Stops the read replicas checker.
+	external       bool          // The pools are owned by the caller, see WithDB.
+	logger         Logger        // The logger of the statements.
+	hooksMu        sync.Mutex
+	slowQueryHooks []slowQueryHook // The handlers of the slow queries.
+	beforeHooks    []func(ctx context.Context, sql string, bindings []interface{})
+	listeners      []func(QueryExecuted)
+	txListeners    []func(TransactionEvent)
+	retryPolicy    *RetryPolicy // The policy set by SetRetryPolicy, it outlives the reloads.
 }
 
-func hasReadWrite(c *DBConfig) (hasRead bool) {
-	if c.ReadHost != nil && c.ReadHost[0] != "" &&
-		c.WriteHost != nil && c.WriteHost[0] != "" {
-		hasRead = true
-	}
-	return
+// ErrConnectionClosed is returned when a query is run on a closed connection.
+var ErrConnectionClosed = errors.New("builder: connection is closed")
+
+// defaultConnectBackoff the wait before the first connect retry.
+const defaultConnectBackoff = 100 * time.Millisecond
+
+// hasReadWrite reports whether the config has read replicas, the writes
+// go to the write hosts or to the host.
+func hasReadWrite(c *DBConfig) bool {
+	return countHosts(c.ReadHost) > 0
 }
 
-func makeDB(config DBConfig, DBType ...string) *sql.DB {
+// openDB open a pool to host, or to the configured host when host is
+// empty. The settings of pool override the shared pool of the config. It
+// does not dial the database.
+func openDB(config DBConfig, host string, pool PoolSettings) (*sql.DB, error) {
+	if host != "" {
+		config.Host = host
+	}
 
-	if DBType != nil && DBType[0] == "write" {
-		config.Host = config.WriteHost[0]
-		// log.Printf("\x1b[92m DB Write Host: %#v \x1b[39m", config.Host)
+	if config.Driver == "mysql" && config.TLS.enabled() {
+		if err := registerMysqlTLS(config.TLS); err != nil {
+			return nil, fmt.Errorf("tls: %w", err)
+		}
 	}
-	if DBType != nil && DBType[0] == "read" {
-		config.Host = config.ReadHost[0]
-		// log.Printf("\x1b[92m DB Read Host: %#v \x1b[39m", config.Host)
+
+	driverName, err := sqlDriverName(config.Driver)
+	if err != nil {
+		return nil, err
 	}
 
 	dsn := getDsn(config)
-	// log.Printf("\x1b[92m sql.Open(%#v, %#v) \x1b[39m", config.Driver, dsn)
-	db, err := sql.Open(config.Driver, dsn)
+	db, err := sql.Open(driverName, dsn)
 	if err != nil {
-		log.Fatalf("\x1b[31m sql.Open:\x1b[39m %s", err.Error())
+		return nil, fmt.Errorf("sql.Open: %w", err)
 	}
-	// 建立连接
-	if err = db.Ping(); err != nil {
-		log.Fatalf("\x1b[31m sql.Ping: \x1b[39m %s", err.Error())
+	configurePool(db, config.Pool.merge(pool))
+
+	return db, nil
+}
+
+// configurePool apply the pool settings to db.
+func configurePool(db *sql.DB, pool PoolSettings) {
+	if pool.MaxOpenConns != 0 {
+		db.SetMaxOpenConns(pool.MaxOpenConns)
 	}
+	if pool.MaxIdleConns != 0 {
+		db.SetMaxIdleConns(pool.MaxIdleConns)
+	}
+	if pool.ConnMaxLifetime != 0 {
+		db.SetConnMaxLifetime(pool.ConnMaxLifetime)
+	}
+	if pool.ConnMaxIdleTime != 0 {
+		db.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+	}
+}
+
+// PoolSettings Get the pool settings of the write and the read DB.
+func (m *Connection) PoolSettings() (write, read PoolSettings) {
+	m.stateMu.Lock()
+	config := m.Config
+	m.stateMu.Unlock()
 
-	return db
+	write = config.Pool.merge(config.WritePool)
+	if !hasReadWrite(&config) {
+		return write, write
+	}
+	return write, config.Pool.merge(config.ReadPool)
 }
 
-// configureDBDsn
+// Stats Get the statistics of the write and the read pools, keyed by
+// "write" and "read:<host>" for every read replica.
+func (m *Connection) Stats() map[string]sql.DBStats {
+	stats := map[string]sql.DBStats{}
+	if db := m.writeDB(); db != nil {
+		stats["write"] = db.Stats()
+	}
+	_, replicas := m.hosts()
+	for _, r := range replicas {
+		stats["read:"+r.host] = r.db.Stats()
+	}
+	return stats
+}
+
+// Ping verifies the write and the read pool are still alive.
+func (m *Connection) Ping(ctx context.Context) error {
+	if err := m.connect(ctx); err != nil {
+		return err
+	}
+
+	db := m.writeDB()
+	if db == nil {
+		return ErrConnectionClosed
+	}
+
+	var errs []error
+	if err := db.PingContext(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("write: %w", err))
+	}
+
+	// the reads fall back to the write DB, so only report the replicas
+	// once all of them are down
+	var readErrs []error
+	_, replicas := m.hosts()
+	for _, r := range replicas {
+		if err := r.ping(ctx); err != nil {
+			readErrs = append(readErrs, fmt.Errorf("read %s: %w", r.host, err))
+		}
+	}
+	if len(replicas) > 0 && len(readErrs) == len(replicas) {
+		errs = append(errs, readErrs...)
+	}
+	return errors.Join(errs...)
+}
+
+// configureDBDsn the parameters of the DSN, the session settings of the
+// config are applied by the driver to every new connection.
 func configureDBDsn(config DBConfig) (params string) {
 	switch config.Driver {
 	case "mysql":
-		params = "loc=Local"
+		loc := "Local"
+		if config.Loc != "" {
+			loc = config.Loc
+		}
+		params = "loc=" + url.QueryEscape(loc)
 		if config.Collation != "" {
 			params += "&collation=" + config.Collation
 		}
 		if config.Charset != "" {
 			params += "&charset=" + config.Charset
 		}
-		return
-	case "postgres":
-		port := "5432"
-		if config.Port != "" {
-			port = config.Port
+		if config.ParseTime {
+			params += "&parseTime=true"
+		}
+		if config.TLS.enabled() {
+			params += "&tls=" + config.TLS.name()
+		}
+		// the unknown parameters are SET as system variables
+		if config.Timezone != "" {
+			params += "&time_zone=" + url.QueryEscape("'"+config.Timezone+"'")
 		}
-		params = "port=" + port
+		if config.SQLMode != "" {
+			params += "&sql_mode=" + url.QueryEscape("'"+config.SQLMode+"'")
+		}
+	case "postgres":
 		if config.Sslmode != "" {
 			params += "&sslmode=" + config.Sslmode
 		}
+		if config.TLS.enabled() {
+			params += pgsqlTLSParams(config)
+		}
+		// the unknown parameters are sent as run-time parameters
+		if config.ApplicationName != "" {
+			params += "&application_name=" + url.QueryEscape(config.ApplicationName)
+		}
+		if config.SearchPath != "" {
+			params += "&search_path=" + url.QueryEscape(config.SearchPath)
+		}
+		if config.StatementTimeout > 0 {
+			params += "&statement_timeout=" + strconv.FormatInt(config.StatementTimeout.Milliseconds(), 10)
+		}
+		params = strings.TrimPrefix(params, "&")
 	case "sqlite3":
-		return
-	default:
-		return
+		var pragmas []string
+		if config.JournalMode != "" {
+			pragmas = append(pragmas, sqlitePragma(config, "journal_mode", config.JournalMode))
+		}
+		if config.BusyTimeout > 0 {
+			pragmas = append(pragmas, sqlitePragma(config, "busy_timeout", strconv.FormatInt(config.BusyTimeout.Milliseconds(), 10)))
+		}
+		if config.ForeignKeys {
+			pragmas = append(pragmas, sqlitePragma(config, "foreign_keys", "1"))
+		}
+		params = strings.Join(pragmas, "&")
+	}
+
+	for _, key := range sortedKeys(config.Params) {
+		if params != "" {
+			params += "&"
+		}
+		params += key + "=" + url.QueryEscape(config.Params[key])
 	}
 	return
 }
 
+// sortedKeys return the keys of m in order, so that the DSN is stable.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // getDsn create a DSN string from a configuration.
 // like:
 // user@unix(/path/to/socket)/dbname
@@ -103,14 +261,17 @@ func getDsn(config DBConfig) string {
 		dsn = getHostDsn(config)
 	}
 
-	return dsn + "?" + configureDBDsn(config)
+	if params := configureDBDsn(config); params != "" {
+		dsn += "?" + params
+	}
+	return dsn
 }
 
 // getSocketDsn get the DSN string for a socket configuration.
 // user@unix(/path/to/socket)/dbname
 // root:pw@unix(/tmp/mysql.sock)/myDatabase?loc=Local
 func getSocketDsn(config DBConfig) string {
-	return config.Username + ":" + config.Password + "unix(" + config.UnixSocket + ")/" + config.Database
+	return config.Username + ":" + config.Password + "@unix(" + config.UnixSocket + ")/" + config.Database
 }
 
 // getHostDsn get the DSN string for a host / port configuration.
@@ -123,9 +284,24 @@ func getSocketDsn(config DBConfig) string {
 func getHostDsn(config DBConfig) string {
 	switch config.Driver {
 	case "mysql":
-		return config.Username + ":" + config.Password + "@tcp(" + config.Host + ":" + config.Port + ")/" + config.Database
+		port := config.Port
+		if port == "" {
+			port = "3306"
+		}
+		return config.Username + ":" + config.Password + "@tcp(" + net.JoinHostPort(config.Host, port) + ")/" + config.Database
 	case "postgres":
-		return "postgres://" + config.Username + ":" + config.Password + "@" + config.Host + "/" + config.Database
+		port := config.Port
+		if port == "" {
+			port = "5432"
+		}
+		// the credentials are escaped, they may contain any of ":@/?#"
+		u := url.URL{
+			Scheme: "postgres",
+			User:   url.UserPassword(config.Username, config.Password),
+			Host:   net.JoinHostPort(config.Host, port),
+			Path:   "/" + config.Database,
+		}
+		return u.String()
 	case "sqlite3":
 		return config.Database
 	default:
@@ -133,38 +309,224 @@ func getHostDsn(config DBConfig) string {
 	}
 }
 
-// Connect Establish a connection based on the configuration.
-func (m *Connection) Connect() {
+// Connect Establish a connection based on the configuration. The
+// connections are made lazily on the first query, Connect may be used to
+// validate the connection eagerly.
+func (m *Connection) Connect(ctx context.Context) error {
+	m.stateMu.Lock()
+	m.closing = false
+	m.stateMu.Unlock()
+
+	return m.connect(ctx)
+}
+
+// connect dial the database once, retrying by the connect policy of the
+// configuration.
+func (m *Connection) connect(ctx context.Context) error {
+	m.connectMu.Lock()
+	defer m.connectMu.Unlock()
+
+	if m.connected {
+		return nil
+	}
+
+	// 1. create Write Connection, the first write host which answers
+	primaries, err := makePrimaries(m.Config)
+	if err != nil {
+		return fmt.Errorf("connect %s: %w", m.Config.Driver, err)
+	}
+	err = retryConnect(ctx, m.Config, func() error { return m.connectPrimary(ctx, primaries) })
+	if err != nil {
+		closeHosts(primaries)
+		return fmt.Errorf("connect %s: %w", m.Config.Driver, err)
+	}
 
 	if hasReadWrite(&m.Config) {
-		// 1. create Write Connection
-		m.DB = makeDB(m.Config, "write")
-		// 2. create Read Connection
-		m.DBRead = makeDB(m.Config, "read")
-	} else {
-		m.DB = makeDB(m.Config)
+		// 2. create Read Connections, one per read host
+		replicas, err := makeReplicas(m.Config)
+		if err != nil {
+			closeHosts(m.detachHosts())
+			return fmt.Errorf("connect %s: %w", m.Config.Driver, err)
+		}
+		if len(replicas) > 0 {
+			m.stateMu.Lock()
+			m.replicas, m.DBRead = replicas, replicas[0].db
+			m.stateMu.Unlock()
+
+			interval := m.Config.ReadCheckInterval
+			if interval <= 0 {
+				interval = defaultCheckInterval
+			}
+			m.stopCheck = make(chan struct{})
+			go m.checkReplicas(replicas, interval, m.stopCheck)
+		}
+	}
+
+	m.connected = true
+	return nil
+}
+
+// retryConnect run connect until it succeeds, ctx is done or the retries
+// of the config are used up, the backoff doubles after each attempt.
+func retryConnect(ctx context.Context, config DBConfig, connect func() error) error {
+	backoff := config.ConnectBackoff
+	if backoff <= 0 {
+		backoff = defaultConnectBackoff
+	}
+
+	for attempt := 0; ; attempt++ {
+		err := connect()
+		if err == nil || attempt >= config.ConnectRetries {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if config.ConnectMaxBackoff > 0 && backoff > config.ConnectMaxBackoff {
+			backoff = config.ConnectMaxBackoff
+		}
+	}
+}
+
+// Disconnect from the underlying database without waiting for the
+// running queries.
+func (m *Connection) Disconnect() { m.closeDB() }
+
+// Close drains the running queries and closes the read and write pools.
+func (m *Connection) Close() error { return m.Shutdown(context.Background()) }
+
+// Shutdown stops accepting new queries, waits for the running queries to
+// finish or ctx to be done, then closes the read and write pools.
+func (m *Connection) Shutdown(ctx context.Context) error {
+	m.stateMu.Lock()
+	m.closing = true
+	m.stateMu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		m.inFlight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	return errors.Join(err, m.closeDB())
+}
+
+// closeDB closes the read and write pools.
+func (m *Connection) closeDB() error {
+	m.connectMu.Lock()
+	defer m.connectMu.Unlock()
+
+	// the pools of the caller are closed by the caller
+	if m.external {
+		return nil
+	}
+
+	return closeHosts(m.detachHosts())
+}
+
+// detachHosts take the pools out of the connection, so that the next query
+// connects again. connectMu must be held.
+func (m *Connection) detachHosts() []*hostDB {
+	m.connected = false
+	if m.stopCheck != nil {
+		close(m.stopCheck)
+		m.stopCheck = nil
+	}
+
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	hosts := append(append([]*hostDB(nil), m.primaries...), m.replicas...)
+	m.primaries, m.replicas = nil, nil
+	m.DB, m.DBRead = nil, nil
+	return hosts
+}
+
+// closeHosts close the pools of the hosts.
+func closeHosts(hosts []*hostDB) error {
+	var errs []error
+	for _, h := range hosts {
+		errs = append(errs, h.db.Close())
 	}
+	return errors.Join(errs...)
+}
+
+// beginQuery marks a query as running, it reports false when the
+// connection is closing. The statements of a running transaction are still
+// accepted, so that it can finish.
+func (m *Connection) beginQuery(ctx context.Context) bool {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	if m.closing && m.transactionOf(ctx) == nil {
+		return false
+	}
+	m.inFlight.Add(1)
+	return true
+}
+
+// failClosed fail the statement of the Builder because the connection is
+// closing, the other statements keep running. The failure is reported to
+// the logger and to the listeners only.
+func (m *Connection) failClosed() {
+	m.logStatement(0, 0, ErrConnectionClosed)
+	m.fireQueryExecuted(0, 0, ErrConnectionClosed)
+	m.Grammar.GetBuilder().Reset()
 }
 
 // Run a SQL statement and log its execution context.
 func (m *Connection) run(callback func() ([]map[string]interface{}, int64, error)) ([]map[string]interface{}, int64) {
 
+	if !m.beginQuery(m.Grammar.GetBuilder().context()) {
+		m.failClosed()
+		return nil, 0
+	}
+	defer m.inFlight.Done()
+
+	if !m.Pretending {
+		m.fireBeforeExecuting()
+	}
+
 	start := time.Now()
 
 	// 开始执行callback 返回结果集，受影响的行数，发生错误
 	result, rowCnt, err := callback()
+
+	if !m.Pretending {
+		if result != nil {
+			rowCnt = int64(len(result))
+		}
+		elapsed := time.Since(start)
+		m.logStatement(elapsed, rowCnt, err)
+		m.fireQueryExecuted(elapsed, rowCnt, err)
+	}
 	if err != nil {
 		log.Fatalf("%s", err.Error())
 	}
 
-	if m.Grammar.GetBuilder().debug {
-		log.Printf("\x1b[92m DEBUG SQL:\x1b[39m %#v\n\t\x1b[92m Bindings:\x1b[39m %v Use: %v\n",
-			m.Grammar.GetBuilder().PSql, m.Grammar.GetBuilder().PArgs, time.Since(start))
+	if m.Grammar.GetBuilder().debug && m.getLogger() == nil {
+		log.Printf("\x1b[92m DEBUG SQL:\x1b[39m %#v\n\t\x1b[92m Bindings:\x1b[39m %v Use: %v Attempts: %d\n",
+			m.Grammar.GetBuilder().PSql, m.Grammar.GetBuilder().PArgs, time.Since(start), m.Grammar.GetBuilder().attempts)
 	}
 
 	// Once we have run the query we will calculate the time that it took to run and
 	// then log the query
-	m.logQuery(m.Grammar.GetBuilder().PSql, m.Grammar.GetBuilder().PArgs, time.Since(start))
+	m.logQuery(time.Since(start), rowCnt)
+	if !m.Pretending {
+		m.detectSlowQuery(time.Since(start), rowCnt)
+	}
 
 	// resets the Builder
 	m.Grammar.GetBuilder().Reset()
@@ -172,17 +534,6 @@ func (m *Connection) run(callback func() ([]map[string]interface{}, int64, error
 	return result, rowCnt
 }
 
-// Log a query in the connection's query log.
-func (m *Connection) logQuery(query string, bindings []interface{}, elapsed time.Duration) {
-	if m.loggingQueries {
-		m.queryLog = append(m.queryLog, map[string]interface{}{
-			"query":    query,
-			"bindings": bindings,
-			"time":     elapsed.String(),
-		})
-	}
-}
-
 // AffectingStatement Run an SQL statement and get the number of rows affected.
 func (m *Connection) AffectingStatement() int64 {
 
@@ -192,20 +543,42 @@ func (m *Connection) AffectingStatement() int64 {
 			return nil, 0, nil
 		}
 
-		stmt, err := m.DB.Prepare(m.Grammar.GetBuilder().PSql)
-		if err != nil {
-			return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
+		ctx := m.Grammar.GetBuilder().context()
+		if err := m.connect(ctx); err != nil {
+			return nil, 0, err
+		}
+
+		var res sql.Result
+		exec := func(db sqlConn) error {
+			stmt, err := db.PrepareContext(ctx, m.Grammar.GetBuilder().PSql)
+			if err != nil {
+				return err
+			}
+			defer stmt.Close()
+
+			res, err = stmt.ExecContext(ctx, m.Grammar.GetBuilder().PArgs...)
+			return err
 		}
-		defer stmt.Close()
 
-		res, err := stmt.Exec(m.Grammar.GetBuilder().PArgs...)
+		var err error
+		m.Grammar.GetBuilder().pool = PoolWrite
+		if tx := m.transactionOf(ctx); tx != nil {
+			// a statement of a transaction is not retried
+			m.Grammar.GetBuilder().attempts, err = 1, exec(tx)
+		} else {
+			m.Grammar.GetBuilder().attempts, err = m.retry(ctx, true, func() error {
+				return m.withWriteDB(ctx, func(db *sql.DB) error { return exec(db) })
+			})
+		}
 		if err != nil {
 			return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
 		}
 		// lastId, _ := res.LastInsertId()
 		rowCnt, _ := res.RowsAffected()
 
-		m.recordsHaveBeenModified(rowCnt > 0)
+		if rowCnt > 0 {
+			m.markModified(ctx)
+		}
 
 		return nil, rowCnt, nil
 	})
@@ -215,27 +588,24 @@ func (m *Connection) AffectingStatement() int64 {
 
 // Pretend run dry mode
 func (m *Connection) Pretend(fn func()) []map[string]interface{} {
-	return m.withFreshQueryLog(func() []map[string]interface{} {
+	entries := m.withFreshQueryLog(func() {
 		m.Pretending = true
 
 		fn()
 
 		m.Pretending = false
-		return m.queryLog
 	})
-}
-
-func (m *Connection) withFreshQueryLog(callback func() []map[string]interface{}) []map[string]interface{} {
-	lg := m.loggingQueries
-
-	m.loggingQueries = true
 
-	m.queryLog = []map[string]interface{}{}
-	result := callback()
-
-	m.loggingQueries = lg
-
-	return result
+	pretended := make([]map[string]interface{}, 0, len(entries))
+	for _, e := range entries {
+		pretended = append(pretended, map[string]interface{}{
+			"query":    e.SQL,
+			"bindings": e.Bindings,
+			"time":     e.Duration.String(),
+			"attempts": e.Attempts,
+		})
+	}
+	return pretended
 }
 
 // Select Run a select statement against the database.
@@ -250,57 +620,74 @@ func (m *Connection) Select(useReadDB bool) []map[string]interface{} {
 			return nil, 0, nil
 		}
 
-		var stmt *sql.Stmt
+		ctx := m.Grammar.GetBuilder().context()
+		if err := m.connect(ctx); err != nil {
+			return nil, 0, err
+		}
+
+		var rows *sql.Rows
+		query := func(db sqlConn) (err error) {
+			rows, err = db.QueryContext(ctx, m.Grammar.GetBuilder().PSql, m.Grammar.GetBuilder().PArgs...)
+			return
+		}
+
 		var err error
-		if useReadDB && m.DBRead != nil {
-			log.Printf("\x1b[92m Select use m.DBRead: \x1b[39m%#v", m.DBRead)
-			stmt, err = m.DBRead.Prepare(m.Grammar.GetBuilder().PSql)
+		if tx := m.transactionOf(ctx); tx != nil {
+			// the selects of a transaction see its writes
+			m.Grammar.GetBuilder().pool = PoolWrite
+			m.Grammar.GetBuilder().attempts, err = 1, query(tx)
 		} else {
-			// log.Printf("\x1b[92m Select use m.DB: \x1b[39m%#v", m.DB)
-			stmt, err = m.DB.Prepare(m.Grammar.GetBuilder().PSql)
+			m.Grammar.GetBuilder().attempts, err = m.retry(ctx, false, func() error {
+				if _, replicas := m.hosts(); useReadDB && len(replicas) > 0 && !m.isSticky(ctx) {
+					m.Grammar.GetBuilder().pool = PoolRead
+					return query(m.readDB())
+				}
+				m.Grammar.GetBuilder().pool = PoolWrite
+				// log.Printf("\x1b[92m Select use m.DB: \x1b[39m%#v", m.DB)
+				return m.withWriteDB(ctx, func(db *sql.DB) error { return query(db) })
+			})
 		}
-
 		if err != nil {
 			return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
 		}
-		defer stmt.Close()
+		defer rows.Close()
 
-		rows, err := stmt.Query(m.Grammar.GetBuilder().PArgs...)
+		rowsMap, err := scanRows(rows)
 		if err != nil {
 			return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
 		}
-		defer rows.Close()
 
-		columns, _ := rows.Columns()
+		return rowsMap, 0, nil
+	})
 
-		scanArgs, values := make([]interface{}, len(columns)), make([]sql.RawBytes, len(columns))
+	return results
+}
 
-		for key := range values {
-			scanArgs[key] = &values[key]
-		}
-		rowsMap := make([]map[string]interface{}, 0, 10)
+// scanRows scan the rows into maps of the columns to the string values.
+func scanRows(rows *sql.Rows) ([]map[string]interface{}, error) {
+	columns, _ := rows.Columns()
 
-		for rows.Next() {
-			err := rows.Scan(scanArgs...)
-			if err != nil {
-				return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
-			}
+	scanArgs, values := make([]interface{}, len(columns)), make([]sql.RawBytes, len(columns))
 
-			rowMap := make(map[string]interface{})
-			for key, value := range values {
-				rowMap[columns[key]] = string(value)
-			}
-			rowsMap = append(rowsMap, rowMap)
-		}
+	for key := range values {
+		scanArgs[key] = &values[key]
+	}
+	rowsMap := make([]map[string]interface{}, 0, 10)
 
-		if err = rows.Err(); err != nil {
-			return nil, 0, &queryError{PSql: m.Grammar.GetBuilder().PSql, PArgs: m.Grammar.GetBuilder().PArgs, Err: err}
+	for rows.Next() {
+		err := rows.Scan(scanArgs...)
+		if err != nil {
+			return nil, err
 		}
 
-		return rowsMap, 0, nil
-	})
+		rowMap := make(map[string]interface{})
+		for key, value := range values {
+			rowMap[columns[key]] = string(value)
+		}
+		rowsMap = append(rowsMap, rowMap)
+	}
 
-	return results
+	return rowsMap, rows.Err()
 }
 
 // Exists a select statement
@@ -319,12 +706,6 @@ func (m *Connection) Exists() bool {
 	return true
 }
 
-func (m *Connection) recordsHaveBeenModified(bo bool) {
-	if !m.recordsIsModified {
-		m.recordsIsModified = bo
-	}
-}
-
 // Insert Run an insert statement against the database.
 func (m *Connection) Insert() int64 {
 
@@ -348,34 +729,3 @@ func (m *Connection) Delete() int64 {
 
 	return m.AffectingStatement()
 }
-
-func (m *Connection) execInTransaction(B *Builder) {
-
-	tx, err := m.DB.Begin()
-	if err != nil {
-		log.Fatalf("Whoops! Transaction Begin():%v", err.Error())
-	}
-	defer tx.Rollback()
-	stmt, err := tx.Prepare(B.PSql)
-	if err != nil {
-		log.Fatalf("Whoops! Transaction Prepare():%v", err.Error())
-	}
-
-	_, err = stmt.Exec(B.PArgs...)
-	if err != nil {
-		log.Fatalf("Whoops! Transaction Exec():%v", err.Error())
-	}
-
-	// for i := 0; i < 10; i++ {
-	//	_, err = stmt.Exec(i)
-	//	if err != nil {
-	//		log.Fatal(err)
-	//	}
-	// }
-
-	err = tx.Commit()
-	if err != nil {
-		log.Fatalf("Whoops! Transaction Commit():%v", err.Error())
-	}
-	stmt.Close()
-}
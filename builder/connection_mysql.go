@@ -1,9 +1,5 @@
 package builder
 
-import (
-	_ "github.com/go-sql-driver/mysql"
-)
-
 // MysqlConnection c
 type MysqlConnection struct {
 	Connection
@@ -1,8 +1,25 @@
 package builder
 
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
 // Connector c
 type Connector interface {
-	Connect()
+	// Connect Establish a connection based on the configuration, the
+	// connection is otherwise established by the first query.
+	Connect(ctx context.Context) error
+
+	// Disconnect from the underlying database.
+	Disconnect()
+
+	// Close drains the running queries and closes the underlying pools.
+	Close() error
+
+	// Shutdown is like Close but stops waiting once ctx is done.
+	Shutdown(ctx context.Context) error
 
 	Insert() int64
 
@@ -19,6 +36,53 @@ type Connector interface {
 
 	Exists() bool
 
+	// PoolSettings Get the pool settings of the write and the read DB.
+	PoolSettings() (write, read PoolSettings)
+
+	// Stats Get the statistics of the write and the read pool.
+	Stats() map[string]sql.DBStats
+
+	// Ping verifies the write and the read pool are still alive.
+	Ping(ctx context.Context) error
+
+	// OnFailover Register a hook called when the write host fails over.
+	OnFailover(fn func(from, to string, err error))
+
+	// SetRetryPolicy Set the policy to retry the statements which failed
+	// because the connection to the database was lost.
+	SetRetryPolicy(policy RetryPolicy)
+
+	// EnableQueryLog Enable the query log on the connection.
+	EnableQueryLog(size ...int)
+
+	// DisableQueryLog Disable the query log on the connection.
+	DisableQueryLog()
+
+	// GetQueryLog Get the connection query log.
+	GetQueryLog() []QueryLogEntry
+
+	// FlushQueryLog Clear the query log.
+	FlushQueryLog()
+
+	// WhenQueryingForLongerThan Register a handler of the statements which
+	// run for longer than threshold.
+	WhenQueryingForLongerThan(threshold time.Duration, fn func(SlowQuery))
+
+	// BeforeExecuting Register a hook called before each statement runs.
+	BeforeExecuting(fn func(ctx context.Context, sql string, bindings []interface{}))
+
+	// Listen Register a listener called after each executed statement.
+	Listen(fn func(QueryExecuted))
+
+	// ListenTransaction Register a listener of the transaction events.
+	ListenTransaction(fn func(TransactionEvent))
+
+	// Transaction Run fn in a transaction on the write DB.
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// SetLogger Set the logger of the statements.
+	SetLogger(logger Logger)
+
 	// AffectingStatement Run an SQL statement and get the number of rows affected.
 	AffectingStatement() int64
 }
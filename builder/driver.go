@@ -0,0 +1,57 @@
+package builder
+
+import (
+	"fmt"
+	"sync"
+)
+
+// The build tags which leave a driver out of the binary:
+//
+//	go build -tags nomysql,nopostgres
+//
+// nosqlite leaves out the sqlite driver, sqlite_purego links the pure-Go
+// modernc.org/sqlite instead of the cgo mattn/go-sqlite3.
+
+var (
+	driversMu  sync.RWMutex
+	sqlDrivers = map[string]string{} // The database/sql driver of each driver of the config.
+
+	// registerMysqlTLS register the TLS settings in the mysql driver, set
+	// when go-sql-driver/mysql is linked.
+	registerMysqlTLS = func(TLSConfig) error {
+		return fmt.Errorf("builder: tls needs the go-sql-driver/mysql driver, build without the nomysql tag")
+	}
+)
+
+// RegisterDriver Use the database/sql driver registered as sqlName for the
+// connections of the driver, one of mysql, postgres or sqlite3. The drivers
+// of the build are registered by default, RegisterDriver links any other:
+//
+//	import _ "modernc.org/sqlite"
+//
+//	builder.RegisterDriver("sqlite3", "sqlite")
+func RegisterDriver(driver, sqlName string) {
+	driversMu.Lock()
+	sqlDrivers[driver] = sqlName
+	driversMu.Unlock()
+}
+
+// sqlDriverName Get the database/sql driver of the driver of the config.
+func sqlDriverName(driver string) (string, error) {
+	driversMu.RLock()
+	defer driversMu.RUnlock()
+
+	if name, ok := sqlDrivers[driver]; ok {
+		return name, nil
+	}
+	return "", fmt.Errorf("builder: no %s driver in the build, remove its no* build tag or call RegisterDriver", driver)
+}
+
+// sqlitePragma the DSN parameter of a pragma, mattn/go-sqlite3 and
+// modernc.org/sqlite write them differently.
+func sqlitePragma(config DBConfig, name, value string) string {
+	if driver, _ := sqlDriverName(config.Driver); driver == "sqlite3" {
+		return "_" + name + "=" + value
+	}
+	return "_pragma=" + name + "(" + value + ")"
+}
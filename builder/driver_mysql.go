@@ -0,0 +1,17 @@
+//go:build !nomysql
+
+package builder
+
+import "github.com/go-sql-driver/mysql"
+
+func init() {
+	RegisterDriver("mysql", "mysql")
+
+	registerMysqlTLS = func(c TLSConfig) error {
+		config, err := c.build()
+		if err != nil {
+			return err
+		}
+		return mysql.RegisterTLSConfig(c.name(), config)
+	}
+}
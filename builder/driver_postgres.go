@@ -0,0 +1,7 @@
+//go:build !nopostgres
+
+package builder
+
+import _ "github.com/lib/pq"
+
+func init() { RegisterDriver("postgres", "postgres") }
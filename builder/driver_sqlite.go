@@ -0,0 +1,7 @@
+//go:build !nosqlite && !sqlite_purego && cgo
+
+package builder
+
+import _ "github.com/mattn/go-sqlite3"
+
+func init() { RegisterDriver("sqlite3", "sqlite3") }
@@ -0,0 +1,8 @@
+//go:build !nosqlite && sqlite_purego
+
+package builder
+
+import _ "modernc.org/sqlite"
+
+// the pure-Go sqlite registers itself as "sqlite", it needs no cgo
+func init() { RegisterDriver("sqlite3", "sqlite") }
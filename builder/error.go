@@ -50,3 +50,6 @@ func (e *queryError) Error() string {
 	errString.WriteString("]")
 	return errString.String()
 }
+
+// Unwrap returns the error of the driver.
+func (e *queryError) Unwrap() error { return e.Err }
@@ -0,0 +1,90 @@
+package builder
+
+import (
+	"context"
+	"time"
+)
+
+// QueryExecuted is sent to the listeners after each executed statement.
+type QueryExecuted struct {
+	QueryLogEntry
+	Err error // The error of the statement, if any.
+}
+
+// The actions of a TransactionEvent.
+const (
+	TransactionBeginning  = "beginning"
+	TransactionCommitted  = "committed"
+	TransactionRolledBack = "rolled back"
+)
+
+// TransactionEvent is sent to the transaction listeners when a transaction
+// begins, commits or rolls back.
+type TransactionEvent struct {
+	Connection string // The name of the connection.
+	Action     string // One of TransactionBeginning, TransactionCommitted, TransactionRolledBack.
+	Err        error  // The error which failed the commit, or rolled back the transaction.
+}
+
+// BeforeExecuting Register a hook called before each statement runs, with
+// its SQL and bindings.
+func (m *Connection) BeforeExecuting(fn func(ctx context.Context, sql string, bindings []interface{})) {
+	m.hooksMu.Lock()
+	m.beforeHooks = append(m.beforeHooks, fn)
+	m.hooksMu.Unlock()
+}
+
+// Listen Register a listener called after each executed statement.
+func (m *Connection) Listen(fn func(QueryExecuted)) {
+	m.hooksMu.Lock()
+	m.listeners = append(m.listeners, fn)
+	m.hooksMu.Unlock()
+}
+
+// ListenTransaction Register a listener of the transaction events.
+func (m *Connection) ListenTransaction(fn func(TransactionEvent)) {
+	m.hooksMu.Lock()
+	m.txListeners = append(m.txListeners, fn)
+	m.hooksMu.Unlock()
+}
+
+// fireBeforeExecuting call the before hooks with the statement of the
+// Builder.
+func (m *Connection) fireBeforeExecuting() {
+	m.hooksMu.Lock()
+	hooks := m.beforeHooks
+	m.hooksMu.Unlock()
+
+	b := m.Grammar.GetBuilder()
+	for _, fn := range hooks {
+		fn(b.context(), b.PSql, b.PArgs)
+	}
+}
+
+// fireQueryExecuted call the listeners with the statement of the Builder.
+func (m *Connection) fireQueryExecuted(elapsed time.Duration, rows int64, err error) {
+	m.hooksMu.Lock()
+	listeners := m.listeners
+	m.hooksMu.Unlock()
+
+	if len(listeners) == 0 {
+		return
+	}
+
+	event := QueryExecuted{QueryLogEntry: m.logEntry(elapsed, rows), Err: err}
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
+
+// fireTransaction call the transaction listeners.
+func (m *Connection) fireTransaction(action string, err error) {
+	m.hooksMu.Lock()
+	listeners := m.txListeners
+	m.hooksMu.Unlock()
+
+	event := TransactionEvent{Connection: m.name(), Action: action, Err: err}
+	for _, fn := range listeners {
+		fn(event)
+	}
+}
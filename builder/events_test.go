@@ -0,0 +1,57 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestListen(t *testing.T) {
+	DB, mock := newMockConnection(t)
+
+	var before []string
+	var executed []builder.QueryExecuted
+	var events []string
+	DB.BeforeExecuting(func(ctx context.Context, sql string, bindings []interface{}) { before = append(before, sql) })
+	DB.Listen(func(q builder.QueryExecuted) { executed = append(executed, q) })
+	DB.ListenTransaction(func(e builder.TransactionEvent) { events = append(events, e.Action) })
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("update `users`").ExpectExec().
+		WithArgs("Go", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	err := DB.Transaction(context.Background(), func(ctx context.Context) error {
+		DB.Table("users").WithContext(ctx).Where("id", "1").Update(map[string]string{"name": "Go"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("failed")
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+	if err = DB.Transaction(context.Background(), func(ctx context.Context) error { return failed }); err != failed {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: %v", err, failed)
+	}
+
+	sql := "update `users` set `name` = ? where `id` = ?"
+	if !reflect.DeepEqual(before, []string{sql}) {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m before got: %v want: [%s]", before, sql)
+	}
+	if len(executed) != 1 || executed[0].SQL != sql || executed[0].Rows != 1 || executed[0].Err != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m executed got: %+v", executed)
+	}
+	want := []string{builder.TransactionBeginning, builder.TransactionCommitted, builder.TransactionBeginning, builder.TransactionRolledBack}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m events got: %v want: %v", events, want)
+	}
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
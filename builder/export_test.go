@@ -0,0 +1,13 @@
+package builder
+
+// The unexported functions under test.
+var (
+	CausedByLostConnection = causedByLostConnection
+	IsConnectionError      = isConnectionError
+	RetryConnect           = retryConnect
+	SupportedBalancers     = supportedBalancers
+	GetDsn                 = getDsn
+	ExpandEnv              = expandEnv
+	ReadPasswordFile       = readPasswordFile
+	ConfigFromEnv          = configFromEnv
+)
@@ -0,0 +1,150 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// makePrimaries open one pool per write host, or a pool to the host of the
+// connection when no write host is listed.
+func makePrimaries(config DBConfig) ([]*hostDB, error) {
+	hosts := make([]string, 0, len(config.WriteHost))
+	for _, host := range config.WriteHost {
+		if host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, config.Host)
+	}
+
+	primaries := make([]*hostDB, 0, len(hosts))
+	for _, host := range hosts {
+		db, err := openDB(config, host, config.WritePool)
+		if err != nil {
+			closeHosts(primaries)
+			return nil, err
+		}
+		primaries = append(primaries, &hostDB{host: host, db: db})
+	}
+	return primaries, nil
+}
+
+// connectPrimary use the first write host which answers the ping, the
+// write hosts which do not answer are marked down.
+func (m *Connection) connectPrimary(ctx context.Context, primaries []*hostDB) error {
+	var errs []error
+	for i, p := range primaries {
+		if err := p.ping(ctx); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		m.stateMu.Lock()
+		m.primaries, m.primary, m.DB = primaries, i, p.db
+		m.stateMu.Unlock()
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+// hosts Get the write hosts and the read replicas, they are replaced when
+// the connection is made or closed.
+func (m *Connection) hosts() (primaries, replicas []*hostDB) {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	return m.primaries, m.replicas
+}
+
+// dialed reports whether the pools of the connection are open.
+func (m *Connection) dialed() bool { return m.writeDB() != nil }
+
+// writeDB Get the DB of the write host in use.
+func (m *Connection) writeDB() *sql.DB {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	return m.DB
+}
+
+// OnFailover Register a hook called when the write host fails over, with
+// the failed host, the new host and the error which caused the failover.
+func (m *Connection) OnFailover(fn func(from, to string, err error)) {
+	m.failoverMu.Lock()
+	m.failoverHooks = append(m.failoverHooks, fn)
+	m.failoverMu.Unlock()
+}
+
+// withWriteDB run fn against the write host in use. When fn fails with a
+// connection error, the write host is marked down and fn is run again on
+// the next write host which answers.
+func (m *Connection) withWriteDB(ctx context.Context, fn func(db *sql.DB) error) error {
+	for attempt := 1; ; attempt++ {
+		db := m.writeDB()
+		if db == nil {
+			return ErrConnectionClosed
+		}
+
+		err := fn(db)
+		if primaries, _ := m.hosts(); err == nil || !isConnectionError(err) || attempt >= len(primaries) {
+			return err
+		}
+		if !m.failover(ctx, db, err) {
+			return err
+		}
+	}
+}
+
+// failover switch from the failed DB to the next write host which answers,
+// it reports whether a write host is available.
+func (m *Connection) failover(ctx context.Context, failed *sql.DB, cause error) bool {
+	m.failoverMu.Lock()
+	defer m.failoverMu.Unlock()
+
+	m.stateMu.Lock()
+	primaries, primary, db := m.primaries, m.primary, m.DB
+	m.stateMu.Unlock()
+
+	// another query has already failed over
+	if db != failed {
+		return true
+	}
+
+	from := primaries[primary]
+	from.down.Store(true)
+
+	for offset := 1; offset < len(primaries); offset++ {
+		i := (primary + offset) % len(primaries)
+		to := primaries[i]
+		if to.ping(ctx) != nil {
+			continue
+		}
+
+		m.stateMu.Lock()
+		m.primary, m.DB = i, to.db
+		m.stateMu.Unlock()
+
+		for _, hook := range m.failoverHooks {
+			hook(from.host, to.host, cause)
+		}
+		return true
+	}
+
+	return false
+}
+
+// isConnectionError reports whether err means the host can not be reached,
+// so that the statement never ran and is safe to run on another host.
+func isConnectionError(err error) bool {
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
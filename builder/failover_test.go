@@ -0,0 +1,177 @@
+package builder_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want bool
+	}{
+		{driver.ErrBadConn, true},
+		{fmt.Errorf("exec: %w", syscall.ECONNREFUSED), true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no route to host")}, true},
+		{&net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}, false},
+		{errors.New("Error 1062: Duplicate entry"), false},
+	} {
+		if got := builder.IsConnectionError(test.err); got != test.want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v got: %v want: %v", test.err, got, test.want)
+		}
+	}
+}
+
+// pgError an error of lib/pq with its SQLSTATE.
+type pgError string
+
+func (e pgError) Error() string    { return "pq: " + string(e) }
+func (e pgError) SQLState() string { return string(e) }
+
+func TestCausedByLostConnection(t *testing.T) {
+	for _, test := range []struct {
+		driver string
+		err    error
+		want   bool
+	}{
+		{"mysql", nil, false},
+		{"mysql", driver.ErrBadConn, true},
+		{"mysql", io.ErrUnexpectedEOF, true},
+		{"mysql", errors.New("Error 2006: MySQL server has gone away"), true},
+		{"mysql", errors.New("Error 1062: Duplicate entry"), false},
+		{"postgres", pgError("08006"), true},
+		{"postgres", pgError("57P01"), true},
+		{"postgres", pgError("23505"), false},
+		{"mysql", pgError("08006"), false},
+		{"sqlite3", errors.New("write tcp: broken pipe"), true},
+		{"sqlite3", errors.New("database is locked"), false},
+	} {
+		if got := builder.CausedByLostConnection(test.driver, test.err); got != test.want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s %v got: %v want: %v", test.driver, test.err, got, test.want)
+		}
+	}
+}
+
+func TestRetryConnect(t *testing.T) {
+	failed := errors.New("connection refused")
+	config := builder.DBConfig{ConnectRetries: 3, ConnectBackoff: time.Millisecond, ConnectMaxBackoff: 2 * time.Millisecond}
+
+	// the retries are used up
+	attempts := 0
+	err := builder.RetryConnect(context.Background(), config, func() error { attempts++; return failed })
+	if err != failed || attempts != 4 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v after %d attempts want: %v after 4", err, attempts, failed)
+	}
+
+	// the connect succeeds on a retry
+	attempts = 0
+	err = builder.RetryConnect(context.Background(), config, func() error {
+		if attempts++; attempts < 2 {
+			return failed
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v after %d attempts want: nil after 2", err, attempts)
+	}
+
+	// the retries stop once ctx is done
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	attempts = 0
+	config.ConnectBackoff = time.Hour
+	err = builder.RetryConnect(ctx, config, func() error { attempts++; return failed })
+	if !errors.Is(err, context.Canceled) || attempts != 1 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v after %d attempts want: canceled after 1", err, attempts)
+	}
+}
+
+func TestRetryPolicy(t *testing.T) {
+	DB, mock := newMockConnection(t)
+	DB.SetRetryPolicy(builder.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, RetryWrites: true})
+
+	var attempts []int
+	DB.Listen(func(q builder.QueryExecuted) { attempts = append(attempts, q.Attempts) })
+
+	mock.ExpectQuery("select \\* from `users`").WillReturnError(io.ErrUnexpectedEOF)
+	mock.ExpectQuery("select \\* from `users`").WillReturnError(io.ErrUnexpectedEOF)
+	mock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	if got := DB.Table("users").Get(); len(got) != 1 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: 1 row", got)
+	}
+
+	mock.ExpectPrepare("delete from `users`").WillReturnError(errors.New("invalid connection"))
+	mock.ExpectPrepare("delete from `users`").ExpectExec().
+		WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 1))
+	DB.Table("users").Where("id", "1").Delete()
+
+	if len(attempts) != 2 || attempts[0] != 3 || attempts[1] != 2 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v attempts want: [3 2]", attempts)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestWriteFailover(t *testing.T) {
+	// the write hosts are opened by the postgres driver of the config
+	builder.RegisterDriver("postgres", "sqlmock")
+	defer builder.RegisterDriver("postgres", "postgres")
+
+	config := builder.DBConfig{
+		Driver:    "postgres",
+		Database:  "failover",
+		Username:  "root",
+		WriteHost: []string{"write-1", "write-2"},
+	}
+
+	mocks := map[string]sqlmock.Sqlmock{}
+	for _, host := range config.WriteHost {
+		c := config
+		c.Host = host
+		db, mock, err := sqlmock.NewWithDSN(builder.GetDsn(c), sqlmock.MonitorPingsOption(true))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		mocks[host] = mock
+	}
+
+	DB, err := builder.NewConnection("postgres", builder.WithConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Disconnect()
+
+	var failovers []string
+	DB.OnFailover(func(from, to string, err error) { failovers = append(failovers, from+" => "+to) })
+
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	mocks["write-1"].ExpectPing()
+	mocks["write-1"].ExpectPrepare("insert into").WillReturnError(refused)
+	mocks["write-2"].ExpectPing()
+	mocks["write-2"].ExpectPrepare("insert into").ExpectExec().
+		WithArgs("Go").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	if got := DB.Table("users").Insert([]map[string]string{{"name": "Go"}}); got != 1 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %d rows affected want: 1", got)
+	}
+	if len(failovers) != 1 || failovers[0] != "write-1 => write-2" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: [write-1 => write-2]", failovers)
+	}
+	for host, mock := range mocks {
+		if err = mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s: %v", host, err)
+		}
+	}
+}
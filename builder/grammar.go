@@ -23,4 +23,7 @@ type Grammar interface {
 	CompileExists()
 
 	Wrap(string) string
+
+	// Literal Get the SQL literal of a value, see Builder.ToRawSQL.
+	Literal(value interface{}) string
 }
@@ -33,19 +33,32 @@ func (g *Grammars) GetPlaceholder(s string) string {
 }
 
 // CompileInsert compile an insert statement into SQL.
-func (g *Grammars) CompileInsert() { g.Builder.PSql = g.compileInsert() }
+func (g *Grammars) CompileInsert() {
+	g.PlaceholderNum = 0
+	g.Builder.PSql = g.compileInsert()
+}
 
 // CompileDelete compile an delete statement into SQL.
-func (g *Grammars) CompileDelete() { g.Builder.PSql = g.compileDelete() }
+func (g *Grammars) CompileDelete() {
+	g.PlaceholderNum = 0
+	g.Builder.PSql = g.compileDelete()
+}
 
 // CompileUpdate compile an update statement into SQL.
-func (g *Grammars) CompileUpdate() { g.Builder.PSql = g.compileUpdate() }
+func (g *Grammars) CompileUpdate() {
+	g.PlaceholderNum = 0
+	g.Builder.PSql = g.compileUpdate()
+}
 
 // CompileSelect compile an select statement into SQL.
-func (g *Grammars) CompileSelect() { g.Builder.PSql = g.compileSelect() }
+func (g *Grammars) CompileSelect() {
+	g.PlaceholderNum = 0
+	g.Builder.PSql = g.compileSelect()
+}
 
 // CompileExists com
 func (g *Grammars) CompileExists() {
+	g.PlaceholderNum = 0
 	var sql strings.Builder
 	sql.Grow(1024)
 	sql.WriteString("select exists(")
@@ -0,0 +1,76 @@
+package builder_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestStatsWhileReconnecting(t *testing.T) {
+	DB, err := builder.NewConnection("sqlite3", builder.WithConfig(builder.DBConfig{
+		Database:  filepath.Join(t.TempDir(), "gogogo.sqlite"),
+		ReadHost:  []string{"read-1", "read-2"},
+		WriteHost: []string{"write-1"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+
+	ctx := context.Background()
+	if err = DB.Ping(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if stats := DB.Stats(); len(stats) != 3 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: write, read:read-1 and read:read-2", stats)
+	}
+
+	// run with -race, the pools are replaced while they are read
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			DB.Disconnect()
+			DB.Connect(ctx)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			DB.Stats()
+			if i%10 == 0 {
+				DB.Ping(ctx)
+			}
+		}
+	}()
+	wg.Wait()
+}
+
+func TestHealthCheck(t *testing.T) {
+	DM, err := builder.NewDatabaseManagerFromReader(strings.NewReader(`{"default": "sqlite", "sqlite": {"driver": "sqlite3", "database": ":memory:"}}`), builder.FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DM.Close()
+
+	// the lazy connection is not dialed by the probe
+	DB := DM.Connection("sqlite")
+	if got := DM.HealthCheck(context.Background())["sqlite"]; got.Connected || got.Err != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: not connected", got)
+	}
+	if got := DB.Stats(); len(got) != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: no pools", got)
+	}
+
+	if err = DB.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := DM.HealthCheck(context.Background())["sqlite"]; !got.Connected || !got.Healthy {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: connected and healthy", got)
+	}
+}
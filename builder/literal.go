@@ -0,0 +1,113 @@
+package builder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Literal Get the SQL literal of a value, the standard quoting is used
+// unless the grammar overrides it.
+func (g *Grammars) Literal(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return "'" + strings.Replace(v, "'", "''", -1) + "'"
+	case []byte:
+		return "X'" + hex.EncodeToString(v) + "'"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999") + "'"
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	default:
+		return literalNumber(v)
+	}
+}
+
+// Literal Get the MySQL literal of a value, the backslashes of the
+// strings are escaped.
+func (mg *MySqlGrammars) Literal(value interface{}) string {
+	if v, ok := value.(string); ok {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", "''").Replace(v) + "'"
+	}
+	return mg.Grammars.Literal(value)
+}
+
+// Literal Get the PostgreSQL literal of a value, the bytes are a bytea and
+// the times keep their zone.
+func (pg *PostgresGrammars) Literal(value interface{}) string {
+	switch v := value.(type) {
+	case []byte:
+		return `'\x` + hex.EncodeToString(v) + "'::bytea"
+	case time.Time:
+		return "'" + v.Format("2006-01-02 15:04:05.999999-07:00") + "'"
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return pg.Grammars.Literal(value)
+	}
+}
+
+// literalNumber the literal of the numbers, and of any other value as a
+// quoted string.
+func literalNumber(value interface{}) string {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case fmt.Stringer:
+		return "'" + strings.Replace(v.String(), "'", "''", -1) + "'"
+	default:
+		return "'" + strings.Replace(fmt.Sprint(v), "'", "''", -1) + "'"
+	}
+}
+
+// interpolate replace the ? and $n placeholders of sql by the literals of
+// the bindings. The placeholders in quoted strings and identifiers are
+// kept.
+func interpolate(sql string, bindings []interface{}, literal func(interface{}) string) string {
+	var raw strings.Builder
+	raw.Grow(len(sql))
+
+	next := 0
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			end := strings.IndexByte(sql[i+1:], c)
+			if end < 0 {
+				raw.WriteString(sql[i:])
+				return raw.String()
+			}
+			raw.WriteString(sql[i : i+end+2])
+			i += end + 1
+		case c == '?' && next < len(bindings):
+			raw.WriteString(literal(bindings[next]))
+			next++
+		case c == '$' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			j := i + 1
+			for j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+				j++
+			}
+			n, _ := strconv.Atoi(sql[i+1 : j])
+			if n < 1 || n > len(bindings) {
+				raw.WriteString(sql[i:j])
+			} else {
+				raw.WriteString(literal(bindings[n-1]))
+			}
+			i = j - 1
+		default:
+			raw.WriteByte(c)
+		}
+	}
+	return raw.String()
+}
@@ -0,0 +1,74 @@
+package builder
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Logger logs the statements of a connection, *slog.Logger satisfies it:
+//
+//	DB.SetLogger(slog.Default())
+//
+// The statements are logged at the debug level, in the debug mode at the
+// info level and the failed ones at the error level, with the attributes
+// sql, bindings, duration, rows, connection, attempts and error.
+type Logger interface {
+	Log(ctx context.Context, level slog.Level, msg string, args ...interface{})
+}
+
+// SetLogger Set the logger of the statements, nil logs only the Debug()
+// statements, with the log package.
+func (m *Connection) SetLogger(logger Logger) {
+	m.stateMu.Lock()
+	m.logger = logger
+	m.stateMu.Unlock()
+}
+
+// getLogger Get the logger of the statements, nil if none is set.
+func (m *Connection) getLogger() Logger {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	return m.logger
+}
+
+// name Get the name of the connection in the config, or its driver.
+func (m *Connection) name() string {
+	m.stateMu.Lock()
+	defer m.stateMu.Unlock()
+
+	if m.Config.Name != "" {
+		return m.Config.Name
+	}
+	return m.Config.Driver
+}
+
+// logStatement log the statement of the Builder to the logger.
+func (m *Connection) logStatement(elapsed time.Duration, rows int64, err error) {
+	logger := m.getLogger()
+	if logger == nil {
+		return
+	}
+
+	b := m.Grammar.GetBuilder()
+	level, msg := slog.LevelDebug, "query"
+	if b.debug {
+		level = slog.LevelInfo
+	}
+
+	args := []interface{}{
+		slog.String("sql", b.PSql),
+		slog.Any("bindings", b.PArgs),
+		slog.Duration("duration", elapsed),
+		slog.Int64("rows", rows),
+		slog.String("connection", m.name()),
+		slog.Int("attempts", b.attempts),
+	}
+	if err != nil {
+		level, msg = slog.LevelError, "query failed"
+		args = append(args, slog.Any("error", err))
+	}
+
+	logger.Log(b.context(), level, msg, args...)
+}
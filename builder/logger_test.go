@@ -0,0 +1,77 @@
+package builder_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&out, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	DB, mock := newMockConnection(t, builder.WithLogger(logger))
+
+	mock.ExpectPrepare("delete from `users`").ExpectExec().
+		WithArgs("1").WillReturnResult(sqlmock.NewResult(0, 2))
+	DB.Table("users").Where("id", "1").Delete()
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(out.Bytes(), &entry); err != nil {
+		t.Fatalf("\x1b[91mOops🔥\x1b[39m %v: %s", err, out.String())
+	}
+
+	want := map[string]interface{}{
+		"level":      "DEBUG",
+		"msg":        "query",
+		"sql":        "delete from `users` where `id` = ?",
+		"rows":       float64(2),
+		"connection": "mysql",
+		"attempts":   float64(1),
+	}
+	for key, value := range want {
+		if entry[key] != value {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s got: %v want: %v", key, entry[key], value)
+		}
+	}
+	if _, ok := entry["duration"]; !ok {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m no duration in %s", out.String())
+	}
+
+	// nothing is logged without a logger
+	out.Reset()
+	DB.SetLogger(nil)
+	mock.ExpectPrepare("delete from `users`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	DB.Table("users").Where("id", "1").Delete()
+	if out.Len() != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %s want: no output", out.String())
+	}
+}
+
+func TestLoggerClosedConnection(t *testing.T) {
+	var out bytes.Buffer
+	log.SetOutput(&out)
+	defer log.SetOutput(os.Stderr)
+
+	DB, _ := newMockConnection(t)
+	var failed []error
+	DB.Listen(func(q builder.QueryExecuted) { failed = append(failed, q.Err) })
+	if err := DB.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// a refused statement is only reported to the listeners without a logger
+	DB.Table("users").Get()
+	if out.Len() != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %s want: no output", out.String())
+	}
+	if len(failed) != 1 || failed[0] != builder.ErrConnectionClosed {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: [%v]", failed, builder.ErrConnectionClosed)
+	}
+}
@@ -0,0 +1,34 @@
+package builder_test
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+// newMockDB make a sqlmock DB, it is closed when the test is done.
+func newMockDB(t *testing.T) (*sql.DB, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db, mock
+}
+
+// newMockConnection make a mysql connection on a sqlmock DB, configured by
+// the options.
+func newMockConnection(t *testing.T, opts ...builder.Option) (builder.Connector, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock := newMockDB(t)
+	DB, err := builder.NewConnection("mysql", append([]builder.Option{builder.WithDB(db)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return DB, mock
+}
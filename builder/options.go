@@ -0,0 +1,110 @@
+package builder
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+)
+
+// Option configures a connection made by NewConnection.
+type Option func(*options)
+
+type options struct {
+	config DBConfig
+	edits  []func(*DBConfig) // The fields set by the other options, applied over config.
+	db     *sql.DB
+	readDB []*sql.DB
+	logger Logger
+}
+
+// WithConfig use the config for the fields not set by the other options,
+// the pools are opened from it unless WithDB is given.
+func WithConfig(config DBConfig) Option {
+	return func(o *options) { o.config = config }
+}
+
+// WithDB use an existing pool as the write DB, e.g. the DB of sqlmock. The
+// pool is owned by the caller, closing the connection does not close it.
+func WithDB(db *sql.DB) Option {
+	return func(o *options) { o.db = db }
+}
+
+// WithReadDB use existing pools as the read replicas, the reads are
+// balanced between them by the read strategy of the config. The replicas
+// are named by their index, e.g. read:0 in Stats.
+func WithReadDB(db ...*sql.DB) Option {
+	return func(o *options) { o.readDB = append(o.readDB, db...) }
+}
+
+// WithPrefix set the table prefix.
+func WithPrefix(prefix string) Option {
+	return func(o *options) {
+		o.edits = append(o.edits, func(c *DBConfig) { c.Prefix = prefix })
+	}
+}
+
+// WithLogger set the logger of the statements, see Logger.
+func WithLogger(logger Logger) Option {
+	return func(o *options) { o.logger = logger }
+}
+
+// NewConnection Make a connection for the driver, one of mysql, postgres
+// or sqlite3, configured by the options:
+//
+//	db, mock, _ := sqlmock.New()
+//	DB, err := builder.NewConnection("mysql", builder.WithDB(db), builder.WithPrefix("go_"))
+func NewConnection(driver string, opts ...Option) (Connector, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.db == nil && len(o.readDB) > 0 {
+		return nil, fmt.Errorf("builder: WithReadDB needs WithDB")
+	}
+
+	config := o.config
+	for _, edit := range o.edits {
+		edit(&config)
+	}
+	config.Driver = driver
+	if alias, ok := urlSchemes[driver]; ok {
+		config.Driver = alias
+	}
+
+	conn, err := newConnection(config)
+	if err != nil {
+		return nil, err
+	}
+	if o.db == nil {
+		if _, err = sqlDriverName(config.Driver); err != nil {
+			return nil, err
+		}
+	}
+
+	base := conn.(interface{ base() *Connection }).base()
+	base.SetLogger(o.logger)
+	if o.db != nil {
+		base.useDB(o.db, o.readDB)
+	}
+	return conn, nil
+}
+
+// base Get the Connection of the driver connections.
+func (m *Connection) base() *Connection { return m }
+
+// useDB use pools given by the caller instead of opening them from the
+// config.
+func (m *Connection) useDB(db *sql.DB, readDB []*sql.DB) {
+	m.external = true
+	m.primaries = []*hostDB{{host: m.Config.Host, db: db}}
+	m.DB = db
+
+	for i, r := range readDB {
+		m.replicas = append(m.replicas, &hostDB{host: strconv.Itoa(i), db: r})
+	}
+	if len(m.replicas) > 0 {
+		m.DBRead = m.replicas[0].db
+	}
+	m.connected = true
+}
@@ -0,0 +1,108 @@
+package builder_test
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestNewConnection(t *testing.T) {
+	read, readMock := newMockDB(t)
+	DB, mock := newMockConnection(t, builder.WithReadDB(read), builder.WithPrefix("go_"))
+
+	mock.ExpectPrepare("insert into `go_users`").ExpectExec().
+		WithArgs("Go").WillReturnResult(sqlmock.NewResult(1, 1))
+	readMock.ExpectQuery("select \\* from `go_users`").
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Go"))
+
+	if got := DB.Table("users").Insert([]map[string]string{{"name": "Go"}}); got != 1 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %d rows affected want: 1", got)
+	}
+	if got := DB.Table("users").Select().Get(); len(got) != 1 || got[0]["name"] != "Go" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: [map[name:Go]]", got)
+	}
+
+	// the pools of the caller are left open
+	if err := DB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range []sqlmock.Sqlmock{mock, readMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+		}
+	}
+
+	if _, err := builder.NewConnection("oracle"); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m an unknown driver must fail")
+	}
+}
+
+func TestNewConnectionOptionsOrder(t *testing.T) {
+	// the options override the config whatever their order
+	DB, _ := newMockConnection(t, builder.WithPrefix("go_"), builder.WithConfig(builder.DBConfig{Database: "gogogo"}))
+	if sql, _, err := DB.Table("users").ToSQL(); err != nil || sql != "select * from `go_users`" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %q, %v want: the go_ prefix", sql, err)
+	}
+}
+
+func TestNewConnectionReplicaStats(t *testing.T) {
+	read1, _ := newMockDB(t)
+	read2, _ := newMockDB(t)
+	DB, _ := newMockConnection(t, builder.WithReadDB(read1, read2))
+
+	// each injected replica has its own stats
+	stats := DB.Stats()
+	for _, key := range []string{"write", "read:0", "read:1"} {
+		if _, ok := stats[key]; !ok {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: the %s stats", stats, key)
+		}
+	}
+}
+
+func TestNewConnectionPool(t *testing.T) {
+	DB, err := builder.NewConnection("sqlite3", builder.WithConfig(builder.DBConfig{
+		Database: filepath.Join(t.TempDir(), "gogogo.sqlite"),
+		Pool:     builder.PoolSettings{MaxOpenConns: 3, ConnMaxLifetime: time.Minute},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+
+	if err = DB.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// the shared pool applies to the write and the read DB
+	write, read := DB.PoolSettings()
+	if write.MaxOpenConns != 3 || read.MaxOpenConns != 3 || write.ConnMaxLifetime != time.Minute {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v %+v want: 3 max open conns", write, read)
+	}
+	if got := DB.Stats()["write"].MaxOpenConnections; got != 3 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %d max open connections want: 3", got)
+	}
+}
+
+func TestConnectUnknownDriver(t *testing.T) {
+	// the pools are opened by a database/sql driver which is not linked
+	builder.RegisterDriver("postgres", "gogogo")
+	defer builder.RegisterDriver("postgres", "postgres")
+
+	DB, err := builder.NewConnection("postgres", builder.WithConfig(builder.DBConfig{Host: "localhost", Database: "gogogo"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the error is returned instead of exiting
+	if err = DB.Connect(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown driver") {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: an unknown driver", err)
+	}
+	if err = DB.Ping(context.Background()); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the ping must fail")
+	}
+}
@@ -0,0 +1,155 @@
+package builder
+
+import "time"
+
+// The pools of a connection.
+const (
+	PoolRead  = "read"
+	PoolWrite = "write"
+)
+
+// defaultQueryLogSize the most entries kept in the query log by default.
+const defaultQueryLogSize = 1000
+
+// QueryLogEntry a query of the query log.
+type QueryLogEntry struct {
+	SQL        string
+	Bindings   []interface{}
+	Duration   time.Duration
+	Rows       int64  // The rows affected, or returned by a select.
+	Connection string // The name of the connection.
+	Pool       string // PoolRead or PoolWrite, empty when pretending.
+	Attempts   int
+	Time       time.Time // When the query finished.
+}
+
+// EnableQueryLog Enable the query log on the connection. The log keeps the
+// last size entries, 1000 by default.
+func (m *Connection) EnableQueryLog(size ...int) {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	m.loggingQueries = true
+	if size != nil && size[0] > 0 {
+		m.queryLog.resize(size[0])
+	} else {
+		m.queryLog.resize(defaultQueryLogSize)
+	}
+}
+
+// DisableQueryLog Disable the query log on the connection, the logged
+// entries are kept.
+func (m *Connection) DisableQueryLog() {
+	m.logMu.Lock()
+	m.loggingQueries = false
+	m.logMu.Unlock()
+}
+
+// LoggingQueries Determine whether we're logging queries.
+func (m *Connection) LoggingQueries() bool {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	return m.loggingQueries
+}
+
+// GetQueryLog Get a copy of the connection query log, the oldest first.
+func (m *Connection) GetQueryLog() []QueryLogEntry {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	return m.queryLog.list()
+}
+
+// FlushQueryLog Clear the query log.
+func (m *Connection) FlushQueryLog() {
+	m.logMu.Lock()
+	m.queryLog = queryLog{size: m.queryLog.size}
+	m.logMu.Unlock()
+}
+
+// logQuery Log the query of the Builder in the query log.
+func (m *Connection) logQuery(elapsed time.Duration, rows int64) {
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	if !m.loggingQueries {
+		return
+	}
+
+	m.queryLog.add(m.logEntry(elapsed, rows))
+}
+
+// logEntry Get the log entry of the query of the Builder.
+func (m *Connection) logEntry(elapsed time.Duration, rows int64) QueryLogEntry {
+	b := m.Grammar.GetBuilder()
+	return QueryLogEntry{
+		SQL:        b.PSql,
+		Bindings:   b.PArgs,
+		Duration:   elapsed,
+		Rows:       rows,
+		Connection: m.name(),
+		Pool:       b.pool,
+		Attempts:   b.attempts,
+		Time:       time.Now(),
+	}
+}
+
+// queryLog a ring buffer of the last queries, once it is full each new
+// entry overwrites the oldest one.
+type queryLog struct {
+	entries []QueryLogEntry
+	oldest  int // The index of the oldest entry once the ring is full.
+	size    int // The most entries kept, defaultQueryLogSize when 0.
+}
+
+// add the entry to the log, in place of the oldest one when it is full.
+func (l *queryLog) add(entry QueryLogEntry) {
+	size := l.size
+	if size <= 0 {
+		size = defaultQueryLogSize
+	}
+	if len(l.entries) < size {
+		l.entries = append(l.entries, entry)
+		return
+	}
+	l.entries[l.oldest] = entry
+	l.oldest = (l.oldest + 1) % size
+}
+
+// list Get a copy of the entries, the oldest first.
+func (l *queryLog) list() []QueryLogEntry {
+	if len(l.entries) == 0 {
+		return nil
+	}
+	entries := make([]QueryLogEntry, 0, len(l.entries))
+	entries = append(entries, l.entries[l.oldest:]...)
+	return append(entries, l.entries[:l.oldest]...)
+}
+
+// resize keep the newest size entries.
+func (l *queryLog) resize(size int) {
+	entries := l.list()
+	if over := len(entries) - size; over > 0 {
+		entries = entries[over:]
+	}
+	l.entries, l.oldest, l.size = entries, 0, size
+}
+
+// withFreshQueryLog run callback with an empty query log, and return the
+// queries it logged. The query log is restored after.
+func (m *Connection) withFreshQueryLog(callback func()) []QueryLogEntry {
+	m.logMu.Lock()
+	saved, logging := m.queryLog, m.loggingQueries
+	m.queryLog, m.loggingQueries = queryLog{size: saved.size}, true
+	m.logMu.Unlock()
+
+	callback()
+
+	m.logMu.Lock()
+	defer m.logMu.Unlock()
+
+	entries := m.queryLog.list()
+	m.queryLog, m.loggingQueries = saved, logging
+	return entries
+}
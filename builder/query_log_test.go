@@ -0,0 +1,57 @@
+package builder_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestQueryLog(t *testing.T) {
+	read, readMock := newMockDB(t)
+	DB, mock := newMockConnection(t, builder.WithReadDB(read))
+
+	DB.EnableQueryLog(2)
+	for _, id := range []string{"1", "2"} {
+		mock.ExpectPrepare("delete from `users`").ExpectExec().
+			WithArgs(id).WillReturnResult(sqlmock.NewResult(0, 1))
+		DB.Table("users").Where("id", id).Delete()
+	}
+	readMock.ExpectQuery("select \\* from `users`").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("3").AddRow("4"))
+	DB.Table("users").Get()
+
+	// the pretended queries are not logged
+	DB.Pretend(func() { DB.Table("users").Where("id", "5").Delete() })
+
+	got := DB.GetQueryLog()
+	if len(got) != 2 {
+		t.Fatalf("\x1b[91mOops🔥\x1b[39m got: %d entries want: 2", len(got))
+	}
+
+	want := []builder.QueryLogEntry{
+		{SQL: "delete from `users` where `id` = ?", Bindings: []interface{}{"2"}, Rows: 1, Connection: "mysql", Pool: builder.PoolWrite, Attempts: 1},
+		{SQL: "select * from `users`", Rows: 2, Connection: "mysql", Pool: builder.PoolRead, Attempts: 1},
+	}
+	for i := range want {
+		got[i].Duration, got[i].Time = 0, want[i].Time
+		if !reflect.DeepEqual(got[i], want[i]) {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %+v\nwant: %+v", got[i], want[i])
+		}
+	}
+
+	// a smaller log keeps the newest entries
+	DB.EnableQueryLog(1)
+	if got = DB.GetQueryLog(); len(got) != 1 || got[0].SQL != want[1].SQL {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: the select", got)
+	}
+
+	DB.FlushQueryLog()
+	DB.DisableQueryLog()
+	mock.ExpectPrepare("delete from `users`").ExpectExec().WillReturnResult(sqlmock.NewResult(0, 0))
+	DB.Table("users").Where("id", "6").Delete()
+	if got = DB.GetQueryLog(); len(got) != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: an empty log", got)
+	}
+}
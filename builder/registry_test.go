@@ -0,0 +1,51 @@
+package builder_test
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestConnectionRegistry(t *testing.T) {
+	DM, err := builder.NewDatabaseManager(builder.DatabaseConfig{
+		Default: "sqlite",
+		SQLite:  builder.SQLiteConfig{Driver: "sqlite3", Database: filepath.Join(t.TempDir(), "gogogo.sqlite")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DM.Close()
+
+	// every goroutine gets the same connection
+	conns := make([]builder.Connector, 10)
+	var wg sync.WaitGroup
+	for i := range conns {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			conns[i] = DM.Connection("")
+		}(i)
+	}
+	wg.Wait()
+	for _, conn := range conns[1:] {
+		if conn != conns[0] {
+			t.Fatalf("\x1b[91mOops🔥\x1b[39m the connection was made more than once")
+		}
+	}
+	if got := DM.Connections(); !reflect.DeepEqual(got, []string{"sqlite"}) {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: [sqlite]", got)
+	}
+
+	if err = DM.Purge("sqlite"); err != nil {
+		t.Fatal(err)
+	}
+	if got := DM.Connections(); len(got) != 0 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: []", got)
+	}
+	if DM.Connection("sqlite") == conns[0] {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the purged connection was kept")
+	}
+}
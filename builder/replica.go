@@ -0,0 +1,115 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync/atomic"
+	"time"
+)
+
+// The strategies to balance the reads between the read replicas.
+const (
+	BalanceRandom     = "random"
+	BalanceRoundRobin = "round-robin"
+	BalanceLeastInUse = "least-in-use"
+)
+
+// defaultCheckInterval the interval to re-check the read replicas.
+const defaultCheckInterval = 30 * time.Second
+
+// hostDB a pool dialed to one of the hosts of the connection.
+type hostDB struct {
+	host string
+	db   *sql.DB
+	down atomic.Bool // Indicates the host failed its last ping.
+}
+
+// ping the host and mark it down or up.
+func (r *hostDB) ping(ctx context.Context) error {
+	err := r.db.PingContext(ctx)
+	r.down.Store(err != nil)
+	return err
+}
+
+// supportedBalancers reports whether the strategy is known.
+func supportedBalancers(strategy string) (support bool) {
+	for _, v := range []string{"", BalanceRandom, BalanceRoundRobin, BalanceLeastInUse} {
+		if strategy == v {
+			support = true
+		}
+	}
+	return
+}
+
+// makeReplicas open one pool per read host. The replicas are not dialed,
+// they are up until checkReplicas ejects the ones which fail the ping.
+func makeReplicas(config DBConfig) ([]*hostDB, error) {
+	replicas := make([]*hostDB, 0, len(config.ReadHost))
+	for _, host := range config.ReadHost {
+		if host == "" {
+			continue
+		}
+		db, err := openDB(config, host, config.ReadPool)
+		if err != nil {
+			closeHosts(replicas)
+			return nil, err
+		}
+		replicas = append(replicas, &hostDB{host: host, db: db})
+	}
+	return replicas, nil
+}
+
+// readDB pick a healthy read replica by the configured strategy, it
+// falls back to the write DB when all of the replicas are down.
+func (m *Connection) readDB() *sql.DB {
+	_, replicas := m.hosts()
+	healthy := make([]*hostDB, 0, len(replicas))
+	for _, r := range replicas {
+		if !r.down.Load() {
+			healthy = append(healthy, r)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return m.writeDB()
+	}
+
+	switch m.Config.ReadStrategy {
+	case BalanceRoundRobin:
+		n := atomic.AddUint64(&m.readNext, 1)
+		return healthy[(n-1)%uint64(len(healthy))].db
+	case BalanceLeastInUse:
+		least := healthy[0]
+		for _, r := range healthy[1:] {
+			if r.db.Stats().InUse < least.db.Stats().InUse {
+				least = r
+			}
+		}
+		return least.db
+	default:
+		return healthy[rand.Intn(len(healthy))].db
+	}
+}
+
+// checkReplicas ping the read replicas at once and then every interval
+// until stop is closed, so that the failed replicas are ejected and the
+// recovered ones are put back.
+func (m *Connection) checkReplicas(replicas []*hostDB, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for _, r := range replicas {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			r.ping(ctx)
+			cancel()
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package builder_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestSupportedBalancers(t *testing.T) {
+	for strategy, want := range map[string]bool{
+		"":                        true,
+		builder.BalanceRandom:     true,
+		builder.BalanceRoundRobin: true,
+		builder.BalanceLeastInUse: true,
+		"weighted":                false,
+	} {
+		if got := builder.SupportedBalancers(strategy); got != want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %q got: %v want: %v", strategy, got, want)
+		}
+	}
+}
+
+func TestRoundRobin(t *testing.T) {
+	reads := make([]sqlmock.Sqlmock, 2)
+	opts := []builder.Option{builder.WithConfig(builder.DBConfig{ReadStrategy: builder.BalanceRoundRobin})}
+	for i := range reads {
+		read, readMock := newMockDB(t)
+		reads[i] = readMock
+		opts = append(opts, builder.WithReadDB(read))
+	}
+
+	DB, mock := newMockConnection(t, opts...)
+
+	for _, i := range []int{0, 1, 0} {
+		reads[i].ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	}
+	for i := 0; i < 3; i++ {
+		DB.Table("users").Get()
+	}
+
+	for _, m := range append(reads, mock) {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+		}
+	}
+}
+
+func TestReplicasDown(t *testing.T) {
+	read, readMock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer read.Close()
+
+	DB, mock := newMockConnection(t, builder.WithReadDB(read))
+
+	// the replica which fails the ping is ejected, the reads fall back to
+	// the write DB
+	readMock.ExpectPing().WillReturnError(errors.New("down"))
+	if err = DB.Ping(context.Background()); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the ping of the replica must fail")
+	}
+
+	mock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	DB.Table("users").Get()
+
+	// the replica which answers again is put back
+	readMock.ExpectPing()
+	if err = DB.Ping(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	readMock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	DB.Table("users").Get()
+
+	for _, m := range []sqlmock.Sqlmock{mock, readMock} {
+		if err = m.ExpectationsWereMet(); err != nil {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+		}
+	}
+}
+
+func TestStickyReads(t *testing.T) {
+	read, readMock := newMockDB(t)
+	DB, mock := newMockConnection(t, builder.WithReadDB(read), builder.WithConfig(builder.DBConfig{Sticky: true}))
+
+	ctx := builder.WithStickyScope(context.Background())
+
+	// the scope reads from the replica until it writes
+	readMock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	DB.Table("users").WithContext(ctx).Get()
+
+	mock.ExpectPrepare("insert into `users`").ExpectExec().
+		WithArgs("Go").WillReturnResult(sqlmock.NewResult(1, 1))
+	DB.Table("users").WithContext(ctx).Insert([]map[string]string{{"name": "Go"}})
+
+	// then it reads its own writes, the other scopes still use the replica
+	mock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Go"))
+	DB.Table("users").WithContext(ctx).Get()
+
+	readMock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	DB.Table("users").WithContext(builder.WithStickyScope(context.Background())).Get()
+
+	for _, m := range []sqlmock.Sqlmock{mock, readMock} {
+		if err := m.ExpectationsWereMet(); err != nil {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+		}
+	}
+}
+
+func TestReadHosts(t *testing.T) {
+	for _, test := range []struct {
+		read  []string
+		write []string
+		want  []string
+	}{
+		{read: []string{"", "read-2"}, want: []string{"write", "read:read-2"}},
+		{read: []string{"read-1"}, write: []string{}, want: []string{"write", "read:read-1"}},
+		{read: []string{}, write: []string{}, want: []string{"write"}},
+	} {
+		DB, err := builder.NewConnection("sqlite3", builder.WithConfig(builder.DBConfig{
+			Database:  filepath.Join(t.TempDir(), "gogogo.sqlite"),
+			ReadHost:  test.read,
+			WriteHost: test.write,
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = DB.Connect(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+
+		// the read hosts get their own pool without write hosts
+		stats := DB.Stats()
+		if len(stats) != len(test.want) {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %v got: %v want: %v", test.read, stats, test.want)
+		}
+		for _, key := range test.want {
+			if _, ok := stats[key]; !ok {
+				t.Errorf("\x1b[91mOops🔥\x1b[39m %v got: %v want: %v", test.read, stats, test.want)
+			}
+		}
+		DB.Close()
+	}
+}
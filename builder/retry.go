@@ -0,0 +1,118 @@
+package builder
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// RetryPolicy the policy to retry the statements which failed because the
+// connection to the database was lost.
+type RetryPolicy struct {
+	MaxAttempts int           // The attempts to run a statement, 1 or less disables the retry.
+	Backoff     time.Duration // The wait between the attempts.
+	RetryWrites bool          // Whether insert, update and delete are retried too.
+}
+
+// lostConnectionMessages the error messages which mean the connection to
+// the database was lost, by driver.
+var lostConnectionMessages = map[string][]string{
+	"mysql": {
+		"invalid connection",
+		"bad connection",
+		"server has gone away",
+		"lost connection",
+		"Error 2006",
+		"Error 2013",
+		"Error 1053",
+		"is running with the --read-only option",
+	},
+	"postgres": {
+		"bad connection",
+		"server closed the connection unexpectedly",
+		"terminating connection due to administrator command",
+		"the database system is shutting down",
+		"the database system is starting up",
+		"SSL connection has been closed unexpectedly",
+	},
+	"sqlite3": {
+		"bad connection",
+	},
+}
+
+// lostConnectionMessagesAny the error messages of the network which mean
+// the connection was lost, for every driver.
+var lostConnectionMessagesAny = []string{
+	"connection reset by peer",
+	"broken pipe",
+	"connection refused",
+	"i/o timeout",
+	"no connection to the server",
+}
+
+// causedByLostConnection reports whether err means the connection to the
+// database was lost, so that running the statement again may succeed.
+func causedByLostConnection(driverName string, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// Postgres: class 08 — connection exception and the shutdown errors.
+	var pgErr interface{ SQLState() string }
+	if driverName == "postgres" && errors.As(err, &pgErr) {
+		state := pgErr.SQLState()
+		if strings.HasPrefix(state, "08") || state == "57P01" || state == "57P02" || state == "57P03" {
+			return true
+		}
+	}
+
+	msg := strings.ToLower(err.Error())
+	for _, messages := range [][]string{lostConnectionMessages[driverName], lostConnectionMessagesAny} {
+		for _, v := range messages {
+			if strings.Contains(msg, strings.ToLower(v)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SetRetryPolicy Set the policy to retry the statements which failed
+// because the connection to the database was lost. It overrides the retry
+// of the config, also after a reload.
+func (m *Connection) SetRetryPolicy(policy RetryPolicy) {
+	m.stateMu.Lock()
+	m.Config.Retry, m.retryPolicy = policy, &policy
+	m.stateMu.Unlock()
+}
+
+// retry run fn by the retry policy, write reports whether fn modifies the
+// database. It returns the attempts which have been made.
+func (m *Connection) retry(ctx context.Context, write bool, fn func() error) (attempts int, err error) {
+	policy := m.Config.Retry
+
+	maxAttempts := policy.MaxAttempts
+	if maxAttempts < 1 || (write && !policy.RetryWrites) {
+		maxAttempts = 1
+	}
+
+	for attempts = 1; ; attempts++ {
+		err = fn()
+		if err == nil || attempts >= maxAttempts || !causedByLostConnection(m.Config.Driver, err) {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return attempts, errors.Join(err, ctx.Err())
+		case <-time.After(policy.Backoff):
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package builder_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestSQLitePragmas(t *testing.T) {
+	DB, err := builder.NewConnection("sqlite3", builder.WithConfig(builder.DBConfig{
+		Database:    filepath.Join(t.TempDir(), "gogogo.sqlite"),
+		JournalMode: "WAL",
+		BusyTimeout: 5 * time.Second,
+		ForeignKeys: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer DB.Close()
+
+	if err = DB.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	db := DB.(*builder.SQLiteConnection).DB
+	for pragma, want := range map[string]string{
+		"journal_mode": "wal",
+		"busy_timeout": "5000",
+		"foreign_keys": "1",
+	} {
+		var got string
+		if err = db.QueryRow("PRAGMA " + pragma).Scan(&got); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m PRAGMA %s got: %s want: %s", pragma, got, want)
+		}
+	}
+}
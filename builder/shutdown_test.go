@@ -0,0 +1,85 @@
+package builder_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestShutdown(t *testing.T) {
+	DB, mock := newMockConnection(t)
+
+	var failed []error
+	DB.Listen(func(q builder.QueryExecuted) {
+		if q.Err != nil {
+			failed = append(failed, q.Err)
+		}
+	})
+
+	mock.ExpectBegin()
+	mock.ExpectPrepare("update `users`").ExpectExec().
+		WithArgs("Go", "1").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	done := make(chan error, 1)
+	err := DB.Transaction(context.Background(), func(ctx context.Context) error {
+		go func() { done <- DB.Shutdown(context.Background()) }()
+
+		// Shutdown waits for the running transaction
+		select {
+		case <-done:
+			t.Errorf("\x1b[91mOops🔥\x1b[39m Shutdown did not wait for the transaction")
+		case <-time.After(20 * time.Millisecond):
+		}
+
+		// the statements of the transaction still run
+		DB.Table("users").WithContext(ctx).Where("id", "1").Update(map[string]string{"name": "Go"})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// a statement on the closed connection fails without exiting
+	if got := DB.Table("users").Get(); got != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: nil", got)
+	}
+	if len(failed) != 1 || failed[0] != builder.ErrConnectionClosed {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: [%v]", failed, builder.ErrConnectionClosed)
+	}
+	if err = DB.Transaction(context.Background(), func(ctx context.Context) error { return nil }); err != builder.ErrConnectionClosed {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: %v", err, builder.ErrConnectionClosed)
+	}
+
+	if err = mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestShutdownDrains(t *testing.T) {
+	DB, mock := newMockConnection(t)
+
+	started, finished := make(chan struct{}), make(chan struct{})
+	DB.BeforeExecuting(func(ctx context.Context, sql string, bindings []interface{}) { close(started) })
+	DB.Listen(func(q builder.QueryExecuted) { close(finished) })
+
+	mock.ExpectQuery("select \\* from `users`").WillDelayFor(50 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+	go DB.Table("users").Get()
+
+	<-started
+	if err := DB.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-finished:
+	default:
+		t.Errorf("\x1b[91mOops🔥\x1b[39m Shutdown did not wait for the running query")
+	}
+}
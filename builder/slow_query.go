@@ -0,0 +1,116 @@
+package builder
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// SlowQuery a statement which ran for longer than a threshold.
+type SlowQuery struct {
+	QueryLogEntry
+	Threshold time.Duration            // The threshold the statement exceeded.
+	Explain   []map[string]interface{} // The plan of the select, when the explain of the config is on.
+}
+
+type slowQueryHook struct {
+	threshold time.Duration
+	fn        func(SlowQuery)
+}
+
+// WhenQueryingForLongerThan Register a handler called with each statement
+// which runs for longer than threshold.
+func (m *Connection) WhenQueryingForLongerThan(threshold time.Duration, fn func(SlowQuery)) {
+	m.hooksMu.Lock()
+	m.slowQueryHooks = append(m.slowQueryHooks, slowQueryHook{threshold: threshold, fn: fn})
+	m.hooksMu.Unlock()
+}
+
+// detectSlowQuery report the query of the Builder to the handlers whose
+// threshold it exceeded, and to the logger when it exceeded the
+// slow_query_threshold of the config.
+func (m *Connection) detectSlowQuery(elapsed time.Duration, rows int64) {
+	m.hooksMu.Lock()
+	hooks := m.slowQueryHooks
+	m.hooksMu.Unlock()
+
+	threshold := m.Config.SlowQueryThreshold
+	if len(hooks) == 0 && (threshold <= 0 || elapsed <= threshold) {
+		return
+	}
+
+	slow := SlowQuery{QueryLogEntry: m.logEntry(elapsed, rows)}
+	explained := false
+	report := func(threshold time.Duration) SlowQuery {
+		if !explained && m.Config.SlowQueryExplain {
+			slow.Explain, explained = m.explain(), true
+		}
+		slow.Threshold = threshold
+		return slow
+	}
+
+	for _, h := range hooks {
+		if elapsed > h.threshold {
+			h.fn(report(h.threshold))
+		}
+	}
+
+	if threshold > 0 && elapsed > threshold {
+		logger := m.getLogger()
+		if logger == nil {
+			logger = slog.Default()
+		}
+
+		q := report(threshold)
+		logger.Log(m.Grammar.GetBuilder().context(), slog.LevelWarn, "slow query",
+			slog.String("sql", q.SQL),
+			slog.Any("bindings", q.Bindings),
+			slog.Duration("duration", q.Duration),
+			slog.Duration("threshold", q.Threshold),
+			slog.String("connection", q.Connection),
+			slog.Any("explain", q.Explain),
+		)
+	}
+}
+
+// explain Get the plan of the select of the Builder, nil for the other
+// statements or when the explain fails.
+func (m *Connection) explain() []map[string]interface{} {
+	b := m.Grammar.GetBuilder()
+	if !strings.HasPrefix(strings.ToLower(strings.TrimSpace(b.PSql)), "select") {
+		return nil
+	}
+
+	explain := "explain "
+	if m.Config.Driver == "sqlite3" {
+		explain = "explain query plan "
+	}
+
+	db := m.writeDB()
+	if b.pool == PoolRead {
+		db = m.readDB()
+	}
+	if db == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(b.context(), 5*time.Second)
+	defer cancel()
+
+	var plan []map[string]interface{}
+	err := func() error {
+		rows, err := db.QueryContext(ctx, explain+b.PSql, b.PArgs...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		plan, err = scanRows(rows)
+		return err
+	}()
+	if err != nil {
+		return nil
+	}
+	return plan
+}
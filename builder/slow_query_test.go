@@ -0,0 +1,52 @@
+package builder_test
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestWhenQueryingForLongerThan(t *testing.T) {
+	var out bytes.Buffer
+	DB, mock := newMockConnection(t,
+		builder.WithConfig(builder.DBConfig{SlowQueryThreshold: 10 * time.Millisecond, SlowQueryExplain: true}),
+		builder.WithLogger(slog.New(slog.NewTextHandler(&out, nil))),
+	)
+
+	var slow []builder.SlowQuery
+	DB.WhenQueryingForLongerThan(10*time.Millisecond, func(q builder.SlowQuery) { slow = append(slow, q) })
+
+	mock.ExpectQuery("select \\* from `users`").WithArgs("Go").WillDelayFor(20 * time.Millisecond).
+		WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("Go"))
+	mock.ExpectQuery("explain select \\* from `users`").WithArgs("Go").
+		WillReturnRows(sqlmock.NewRows([]string{"type"}).AddRow("ALL"))
+	DB.Table("users").Where("name", "Go").Get()
+
+	// a fast statement is not reported
+	mock.ExpectQuery("select \\* from `posts`").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	DB.Table("posts").Get()
+
+	if len(slow) != 1 {
+		t.Fatalf("\x1b[91mOops🔥\x1b[39m got: %d slow queries want: 1", len(slow))
+	}
+	q := slow[0]
+	if q.SQL != "select * from `users` where `name` = ?" || q.Bindings[0] != "Go" ||
+		q.Duration < 20*time.Millisecond || q.Threshold != 10*time.Millisecond {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v", q)
+	}
+	if len(q.Explain) != 1 || q.Explain[0]["type"] != "ALL" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got explain: %v want: [map[type:ALL]]", q.Explain)
+	}
+
+	if !strings.Contains(out.String(), `level=WARN msg="slow query"`) || strings.Contains(out.String(), "posts") {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got log: %s", out.String())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
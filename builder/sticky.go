@@ -0,0 +1,47 @@
+package builder
+
+import (
+	"context"
+	"sync"
+)
+
+type stickyKey struct{}
+
+// stickyScope remembers the connections written in a request scope.
+type stickyScope struct {
+	modified sync.Map // *Connection => struct{}
+}
+
+// WithStickyScope returns a copy of ctx which starts a request scope.
+// When a connection has the sticky option, the selects run with this ctx
+// use the write DB once a write has been run with it, so that a request
+// reads its own writes while the read replicas lag behind.
+//
+//	ctx := builder.WithStickyScope(r.Context())
+//	DB.Table("users").WithContext(ctx).Insert(users)
+//	DB.Table("users").WithContext(ctx).Get() // read from the write DB
+func WithStickyScope(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stickyKey{}, &stickyScope{})
+}
+
+// markModified record that the records of the connection have been
+// modified in the request scope of ctx.
+func (m *Connection) markModified(ctx context.Context) {
+	if scope, ok := ctx.Value(stickyKey{}).(*stickyScope); ok {
+		scope.modified.Store(m, struct{}{})
+	}
+}
+
+// isSticky reports whether the selects run with ctx should use the write
+// DB because of a previous write in the same request scope.
+func (m *Connection) isSticky(ctx context.Context) bool {
+	if !m.Config.Sticky {
+		return false
+	}
+	scope, ok := ctx.Value(stickyKey{}).(*stickyScope)
+	if !ok {
+		return false
+	}
+	_, modified := scope.modified.Load(m)
+	return modified
+}
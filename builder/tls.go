@@ -0,0 +1,111 @@
+package builder
+
+import (
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+)
+
+// TLSConfig the TLS settings of a connection, the files are PEM encoded.
+type TLSConfig struct {
+	CA         string `yaml:"ca"`          // The CA bundle which verifies the server.
+	Cert       string `yaml:"cert"`        // The client certificate.
+	Key        string `yaml:"key"`         // The key of the client certificate.
+	ServerName string `yaml:"server_name"` // The name of the server certificate, the host by default.
+	SkipVerify bool   `yaml:"skip_verify"` // Do not verify the server certificate, for tests only.
+}
+
+// enabled reports whether any TLS setting is set.
+func (c TLSConfig) enabled() bool {
+	return c != TLSConfig{}
+}
+
+// build load the files of the settings into a *tls.Config.
+func (c TLSConfig) build() (*tls.Config, error) {
+	config := &tls.Config{ServerName: c.ServerName, InsecureSkipVerify: c.SkipVerify}
+
+	if c.CA != "" {
+		pem, err := ioutil.ReadFile(c.CA)
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificate in %s", c.CA)
+		}
+	}
+
+	if (c.Cert == "") != (c.Key == "") {
+		return nil, errors.New("cert and key must be set together")
+	}
+	if c.Cert != "" {
+		cert, err := tls.LoadX509KeyPair(c.Cert, c.Key)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// name a stable name of the settings, to register them in the driver.
+func (c TLSConfig) name() string {
+	return fmt.Sprintf("builder-%x", sha1.Sum([]byte(fmt.Sprintf("%#v", c))))
+}
+
+// validateTLS check the TLS settings of a connection.
+func validateTLS(p *configParser, config DBConfig) {
+	if !config.TLS.enabled() {
+		return
+	}
+
+	if _, err := config.TLS.build(); err != nil {
+		p.fail("tls", "%v", err)
+	}
+
+	switch config.Driver {
+	case "postgres":
+		if config.TLS.ServerName != "" {
+			p.fail("tls.server_name", "not supported by lib/pq, the host is verified with sslmode verify-full")
+		}
+		if config.TLS.SkipVerify && (config.Sslmode == "verify-ca" || config.Sslmode == "verify-full") {
+			p.fail("tls.skip_verify", "conflicts with sslmode %s", config.Sslmode)
+		}
+		// lib/pq verifies the server against any sslrootcert
+		if config.TLS.SkipVerify && config.TLS.CA != "" {
+			p.fail("tls.skip_verify", "conflicts with tls.ca, lib/pq verifies the server against it")
+		}
+	case "sqlite3":
+		p.fail("tls", "not supported by sqlite")
+	}
+}
+
+// pgsqlTLSParams the lib/pq parameters of the TLS settings. Without an
+// sslmode, the server is verified against the CA unless skip_verify.
+func pgsqlTLSParams(config DBConfig) (params string) {
+	sslmode := config.Sslmode
+	if sslmode == "" {
+		switch {
+		case config.TLS.SkipVerify:
+			sslmode = "require"
+		case config.TLS.CA != "":
+			sslmode = "verify-full"
+		default:
+			sslmode = "require"
+		}
+		params += "&sslmode=" + sslmode
+	}
+
+	if config.TLS.CA != "" && !config.TLS.SkipVerify {
+		params += "&sslrootcert=" + url.QueryEscape(config.TLS.CA)
+	}
+	if config.TLS.Cert != "" {
+		params += "&sslcert=" + url.QueryEscape(config.TLS.Cert) + "&sslkey=" + url.QueryEscape(config.TLS.Key)
+	}
+	return
+}
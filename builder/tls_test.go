@@ -0,0 +1,108 @@
+package builder_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/qclaogui/database/builder"
+)
+
+// writeCert write a self-signed certificate and its key into dir.
+func writeCert(t *testing.T, dir string) (cert, key string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gogogo"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, key = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(key, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600)
+	return
+}
+
+func TestValidateConfigTLS(t *testing.T) {
+	dir := t.TempDir()
+	cert, key := writeCert(t, dir)
+
+	tests := []struct {
+		tls  string
+		want []string
+	}{
+		{tls: "ca: " + cert + "\n    cert: " + cert + "\n    key: " + key},
+		{tls: "ca: " + cert + "\n    skip_verify: true", want: []string{"config pgsql.tls.skip_verify: conflicts with tls.ca"}},
+		{tls: "ca: " + key, want: []string{"config mysql.tls: no PEM certificate", "config pgsql.tls: no PEM certificate"}},
+		{tls: "cert: " + cert, want: []string{"config mysql.tls: cert and key", "config pgsql.tls: cert and key"}},
+		{tls: "server_name: db.example.com", want: []string{"config pgsql.tls.server_name: not supported"}},
+	}
+
+	for _, tt := range tests {
+		path := filepath.Join(dir, "database.yml")
+		yml := `
+mysql:
+  driver: mysql
+  host: localhost
+  database: gogogo
+  tls:
+    ` + tt.tls + `
+pgsql:
+  driver: postgres
+  host: localhost
+  database: gogogo
+  tls:
+    ` + tt.tls + `
+`
+		if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		errs := builder.ValidateConfig(path)
+		if len(errs) != len(tt.want) {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s\n got: %v\nwant: %v", tt.tls, errs, tt.want)
+			continue
+		}
+		for i, err := range errs {
+			if !strings.HasPrefix(err.Error(), tt.want[i]) {
+				t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %s\nwant: %s", err, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestPgsqlSkipVerifyDsn(t *testing.T) {
+	dsn := builder.GetDsn(builder.DBConfig{
+		Driver:   "postgres",
+		Host:     "localhost",
+		Database: "gogogo",
+		TLS:      builder.TLSConfig{CA: "/etc/ssl/db/ca.pem", SkipVerify: true},
+	})
+
+	// lib/pq would verify the server against the sslrootcert
+	if !strings.Contains(dsn, "sslmode=require") || strings.Contains(dsn, "sslrootcert") {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %s want: sslmode=require without sslrootcert", dsn)
+	}
+}
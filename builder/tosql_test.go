@@ -0,0 +1,108 @@
+package builder_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/qclaogui/database/builder"
+)
+
+func TestToSQL(t *testing.T) {
+	Pg, err := builder.NewConnection("postgres")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		query    func() (string, []interface{}, error)
+		want     string
+		wantBind []interface{}
+	}{
+		{
+			query: func() (string, []interface{}, error) {
+				return Pg.Table("users").Where("name", "Go").Where("age", ">", "20").ToSQL()
+			},
+			want:     "select * from `users` where `name` = $1 and `age` > $2",
+			wantBind: []interface{}{"Go", "20"},
+		},
+		{
+			query: func() (string, []interface{}, error) {
+				return Pg.Table("users").AsInsert([]map[string]string{{"name": "Go"}, {"name": "Gopher"}}).ToSQL()
+			},
+			want:     "insert into `users`(`name`) values ($1), ($2)",
+			wantBind: []interface{}{"Go", "Gopher"},
+		},
+		{
+			query: func() (string, []interface{}, error) {
+				return Pg.Table("users").Where("id", "1").AsUpdate(map[string]string{"name": "Go"}).ToSQL()
+			},
+			want:     "update `users` set `name` = $1 where `id` = $2",
+			wantBind: []interface{}{"Go", "1"},
+		},
+		{
+			query: func() (string, []interface{}, error) {
+				return Pg.Table("users").Where("id", "1").AsDelete().ToSQL()
+			},
+			want:     "delete from `users` where `id` = $1",
+			wantBind: []interface{}{"1"},
+		},
+	}
+
+	for _, tt := range tests {
+		sql, bindings, err := tt.query()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sql != tt.want || !reflect.DeepEqual(bindings, tt.wantBind) {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %s %v\nwant: %s %v", sql, bindings, tt.want, tt.wantBind)
+		}
+	}
+
+	if _, _, err = Pg.Table("users").AsUpdate(map[string]string{}).ToSQL(); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m an update without values must fail")
+	}
+
+	// a Builder without a table fails, the Builder of Pg is still usable
+	if _, _, err = builder.New(Pg).ToSQL(); err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m a query without a table must fail")
+	}
+	if _, _, err = Pg.Table("users").ToSQL(); err != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+	}
+}
+
+func TestToRawSQL(t *testing.T) {
+	Pg, _ := builder.NewConnection("postgres")
+	Mysql, _ := builder.NewConnection("mysql")
+
+	got, err := Pg.Table("users").Where("name", "O'Reilly").Where("age", ">", "20").ToRawSQL()
+	if want := "select * from `users` where `name` = 'O''Reilly' and `age` > '20'"; err != nil || got != want {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %s %v\nwant: %s", got, err, want)
+	}
+
+	got, err = Mysql.Table("users").Where("name", `a\'b`).AsDelete().ToRawSQL()
+	if want := "delete from `users` where `name` = 'a\\\\''b'"; err != nil || got != want {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m\n got: %s %v\nwant: %s", got, err, want)
+	}
+
+	at := time.Date(2018, 3, 6, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		grammar builder.Grammar
+		value   interface{}
+		want    string
+	}{
+		{Pg.(*builder.PostgresConnection).Grammar, nil, "NULL"},
+		{Pg.(*builder.PostgresConnection).Grammar, []byte("Go"), `'\x476f'::bytea`},
+		{Pg.(*builder.PostgresConnection).Grammar, at, "'2018-03-06 10:30:00+00:00'"},
+		{Pg.(*builder.PostgresConnection).Grammar, true, "true"},
+		{Mysql.(*builder.MysqlConnection).Grammar, []byte("Go"), "X'476f'"},
+		{Mysql.(*builder.MysqlConnection).Grammar, at, "'2018-03-06 10:30:00'"},
+		{Mysql.(*builder.MysqlConnection).Grammar, 3.5, "3.5"},
+	}
+	for _, tt := range tests {
+		if got := tt.grammar.Literal(tt.value); got != tt.want {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m Literal(%#v) got: %s want: %s", tt.value, got, tt.want)
+		}
+	}
+}
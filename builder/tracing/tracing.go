@@ -0,0 +1,98 @@
+// Package tracing starts an OpenTelemetry span for each statement of a
+// builder connection. It is built on the BeforeExecuting hooks and the
+// listeners of the connection, so that the builder itself does not link
+// OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/qclaogui/database/builder"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName the instrumentation name of the spans.
+const tracerName = "github.com/qclaogui/database/builder/tracing"
+
+// dbSystems the db.system of the drivers.
+var dbSystems = map[string]string{
+	"mysql":    "mysql",
+	"postgres": "postgresql",
+	"sqlite3":  "sqlite",
+}
+
+// Trace start a span for each statement run on conn, as a child of the span
+// in the context of the query. The spans have the attributes db.system,
+// db.name, db.statement, db.operation, db.rows_affected, and record the
+// error of the failed statements.
+func Trace(conn builder.Connector, tp trace.TracerProvider) {
+	tracer := tp.Tracer(tracerName)
+
+	// the statements of a connection run one at a time
+	var mu sync.Mutex
+	var span trace.Span
+
+	conn.BeforeExecuting(func(ctx context.Context, sql string, bindings []interface{}) {
+		config := configOf(conn)
+		system, ok := dbSystems[config.Driver]
+		if !ok {
+			system = config.Driver
+		}
+
+		operation := sql
+		if i := strings.IndexAny(operation, " \t\n"); i > 0 {
+			operation = operation[:i]
+		}
+		name := strings.ToLower(operation)
+		if config.Database != "" {
+			name += " " + config.Database
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, span = tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				attribute.String("db.system", system),
+				attribute.String("db.name", config.Database),
+				attribute.String("db.statement", sql),
+				attribute.String("db.operation", strings.ToUpper(operation)),
+			))
+	})
+
+	conn.Listen(func(q builder.QueryExecuted) {
+		mu.Lock()
+		s := span
+		span = nil
+		mu.Unlock()
+
+		// the statements refused by a closing connection have no span
+		if s == nil {
+			return
+		}
+
+		s.SetAttributes(attribute.Int64("db.rows_affected", q.Rows))
+		if q.Err != nil {
+			s.RecordError(q.Err)
+			s.SetStatus(codes.Error, q.Err.Error())
+		}
+		s.End()
+	})
+}
+
+// configOf the config of the connection.
+func configOf(conn builder.Connector) builder.DBConfig {
+	switch c := conn.(type) {
+	case *builder.MysqlConnection:
+		return c.Config
+	case *builder.PostgresConnection:
+		return c.Config
+	case *builder.SQLiteConnection:
+		return c.Config
+	}
+	return builder.DBConfig{}
+}
@@ -0,0 +1,72 @@
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+	"github.com/qclaogui/database/builder/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	defer tp.Shutdown(context.Background())
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	DB, err := builder.NewConnection("mysql", builder.WithDB(db), builder.WithConfig(builder.DBConfig{Database: "app"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracing.Trace(DB, tp)
+
+	mock.ExpectPrepare("update `users`").ExpectExec().
+		WithArgs("Go", "1").WillReturnResult(sqlmock.NewResult(0, 2))
+
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+	DB.Table("users").WithContext(ctx).Where("id", "1").Update(map[string]string{"name": "Go"})
+	parent.End()
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("\x1b[91mOops🔥\x1b[39m got: %d spans want: 2", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name != "update app" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %q want: %q", span.Name, "update app")
+	}
+	if span.Parent.SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the span is not a child of the span of the context")
+	}
+
+	got := map[attribute.Key]attribute.Value{}
+	for _, kv := range span.Attributes {
+		got[kv.Key] = kv.Value
+	}
+	want := map[attribute.Key]attribute.Value{
+		"db.system":        attribute.StringValue("mysql"),
+		"db.name":          attribute.StringValue("app"),
+		"db.statement":     attribute.StringValue("update `users` set `name` = ? where `id` = ?"),
+		"db.operation":     attribute.StringValue("UPDATE"),
+		"db.rows_affected": attribute.Int64Value(2),
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m %s got: %v want: %v", k, got[k].Emit(), v.Emit())
+		}
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+	}
+}
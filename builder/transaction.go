@@ -0,0 +1,92 @@
+package builder
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// sqlConn the methods shared by *sql.DB and *sql.Tx to run a statement.
+type sqlConn interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// txKey the key of the transaction in a context.
+type txKey struct{}
+
+// txScope a transaction of a connection.
+type txScope struct {
+	conn *Connection
+	tx   *sql.Tx
+}
+
+// Transaction Run fn in a transaction on the write DB. The statements run
+// with the ctx given to fn, by WithContext, are part of the transaction:
+//
+//	err := DB.Transaction(ctx, func(ctx context.Context) error {
+//		DB.Table("users").WithContext(ctx).Where("id", "1").Update(value)
+//		return nil
+//	})
+//
+// The transaction is committed when fn returns nil, and rolled back when fn
+// returns an error or panics.
+func (m *Connection) Transaction(ctx context.Context, fn func(ctx context.Context) error) (err error) {
+	if m.transactionOf(ctx) != nil {
+		return errors.New("builder: nested transactions are not supported")
+	}
+
+	// the transaction is running until it commits or rolls back, Shutdown
+	// waits for it
+	if !m.beginQuery(ctx) {
+		return ErrConnectionClosed
+	}
+	defer m.inFlight.Done()
+
+	if err = m.connect(ctx); err != nil {
+		return err
+	}
+
+	db := m.writeDB()
+	if db == nil {
+		return ErrConnectionClosed
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	m.fireTransaction(TransactionBeginning, nil)
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			m.fireTransaction(TransactionRolledBack, fmt.Errorf("panic: %v", p))
+			panic(p)
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, &txScope{conn: m, tx: tx})); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			err = errors.Join(err, rbErr)
+		}
+		m.fireTransaction(TransactionRolledBack, err)
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		m.fireTransaction(TransactionRolledBack, err)
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	m.fireTransaction(TransactionCommitted, nil)
+	return nil
+}
+
+// transactionOf Get the transaction of the connection in ctx.
+func (m *Connection) transactionOf(ctx context.Context) *sql.Tx {
+	if scope, ok := ctx.Value(txKey{}).(*txScope); ok && scope.conn == m {
+		return scope.tx
+	}
+	return nil
+}
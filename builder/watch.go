@@ -0,0 +1,240 @@
+package builder
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// The actions of a ReloadEvent.
+const (
+	ReloadRebuilt = "rebuilt" // The connection was reconfigured from the new config.
+	ReloadRemoved = "removed" // The connection was removed from the config and disconnected.
+	ReloadDefault = "default" // The default connection changed.
+	ReloadFailed  = "failed"  // The new config is invalid, the old one is kept.
+)
+
+// defaultWatchInterval the interval to check the config for changes.
+const defaultWatchInterval = time.Second
+
+// ReloadEvent is emitted by Watch when the config changed.
+type ReloadEvent struct {
+	Connection string // The name of the connection, empty for the whole file.
+	Action     string // One of ReloadRebuilt, ReloadRemoved, ReloadDefault, ReloadFailed.
+	Err        error  // Why the reload failed.
+	Time       time.Time
+}
+
+// Watch the config and reload it when it changes, until ctx is done. The
+// yml file is re-read on each tick, so that the rotated password_file
+// secrets and environment variables are picked up as well. Only the
+// connections whose resolved config changed are reconfigured: they keep
+// their instance, hooks and listeners, and the new pools are dialed by the
+// next query while the old ones are closed once their running queries are
+// done. A removed connection is dropped from the registry and disconnected.
+// An invalid config, one without connections or without its default
+// connection, is reported once and the running connections are kept. The
+// events are sent to the returned channel, which is closed when ctx is
+// done.
+func (dm *DatabaseManager) Watch(ctx context.Context, interval ...time.Duration) (<-chan ReloadEvent, error) {
+	if dm.ymlPath == "" {
+		return nil, errors.New("builder: Watch needs a yml file, use Run(ymlPath)")
+	}
+
+	every := defaultWatchInterval
+	if interval != nil && interval[0] > 0 {
+		every = interval[0]
+	}
+
+	if _, err := os.Stat(dm.ymlPath); err != nil {
+		return nil, err
+	}
+
+	events := make(chan ReloadEvent, 16)
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+
+		// the failures are reported once, until the config changes
+		var failed string
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			// the file may be missing while an editor replaces it
+			if _, err := os.Stat(dm.ymlPath); err != nil {
+				continue
+			}
+
+			reloaded := dm.reload()
+			key := failures(reloaded)
+			if key != "" && key == failed {
+				continue
+			}
+			failed = key
+
+			for _, e := range reloaded {
+				e.Time = time.Now()
+				select {
+				case events <- e:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// failures the key of the failed events of a reload, empty if none failed.
+func failures(events []ReloadEvent) string {
+	var key strings.Builder
+	for _, e := range events {
+		if e.Action == ReloadFailed {
+			fmt.Fprintf(&key, "%s: %v\n", e.Connection, e.Err)
+		}
+	}
+	return key.String()
+}
+
+// reload re-read the yml file and reconfigure the connections whose
+// resolved config changed.
+func (dm *DatabaseManager) reload() (events []ReloadEvent) {
+	yml, err := readConfig(dm.ymlPath)
+	if err != nil {
+		return []ReloadEvent{{Action: ReloadFailed, Err: err}}
+	}
+
+	dm.mu.RLock()
+	old := &DatabaseManager{ymlConfig: dm.ymlConfig, isLoaded: true}
+	dm.mu.RUnlock()
+	next := &DatabaseManager{ymlConfig: yml, isLoaded: true}
+
+	// a file read while it is saved may be empty, it keeps the old config
+	names := next.configuredConnections()
+	if len(names) == 0 {
+		return []ReloadEvent{{Action: ReloadFailed, Err: errors.New("builder: no connection is configured")}}
+	}
+	if !contains(names, yml.Default) {
+		return []ReloadEvent{{Connection: yml.Default, Action: ReloadFailed, Err: fmt.Errorf("builder: the default connection %q is not configured", yml.Default)}}
+	}
+
+	// a single invalid connection keeps the whole old config
+	configs := map[string]DBConfig{}
+	for _, name := range names {
+		config, err := next.parseConfig(name)
+		if err = errors.Join(err, validateDBConfig(name, config)); err != nil {
+			events = append(events, ReloadEvent{Connection: name, Action: ReloadFailed, Err: err})
+			continue
+		}
+		configs[name] = config
+	}
+	if events != nil {
+		return events
+	}
+
+	var removed []Connector
+	reconfigure := map[string]DBConfig{}
+
+	dm.mu.Lock()
+	for _, name := range old.configuredConnections() {
+		if _, ok := configs[name]; ok {
+			continue
+		}
+		if conn, ok := dm.connections[name]; ok {
+			removed = append(removed, conn)
+			delete(dm.connections, name)
+		}
+		events = append(events, ReloadEvent{Connection: name, Action: ReloadRemoved})
+	}
+
+	for _, name := range names {
+		config := configs[name]
+		was, _ := old.parseConfig(name)
+		if reflect.DeepEqual(was, config) {
+			continue
+		}
+
+		// the connections not made yet are made from the new config
+		if _, ok := dm.connections[name]; ok {
+			reconfigure[name] = config
+			continue
+		}
+		events = append(events, ReloadEvent{Connection: name, Action: ReloadRebuilt})
+	}
+
+	dm.ymlConfig = yml
+	if yml.Default != old.ymlConfig.Default {
+		dm.defaultName = yml.Default
+		events = append(events, ReloadEvent{Connection: yml.Default, Action: ReloadDefault})
+	}
+
+	conns := make(map[string]Connector, len(reconfigure))
+	for name := range reconfigure {
+		conns[name] = dm.connections[name]
+	}
+	dm.mu.Unlock()
+
+	// the running statements are waited for outside of the registry lock
+	for name, config := range reconfigure {
+		conn, ok := conns[name].(interface{ reconfigure(DBConfig) error })
+		if !ok {
+			continue
+		}
+		if err := conn.reconfigure(config); err != nil {
+			events = append(events, ReloadEvent{Connection: name, Action: ReloadFailed, Err: err})
+			continue
+		}
+		events = append(events, ReloadEvent{Connection: name, Action: ReloadRebuilt})
+	}
+
+	// the handles held by the application are not closed, they dial the
+	// database again on their next query
+	for _, conn := range removed {
+		conn.Disconnect()
+	}
+	return events
+}
+
+// reconfigure swap the config of the connection in place, once its running
+// statement is done. The pools are dialed again from the new config by the
+// next query, the old ones are closed once their queries are done. The
+// hooks, listeners, logger and retry policy are kept.
+func (m *Connection) reconfigure(config DBConfig) error {
+	b := m.Grammar.GetBuilder()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	m.connectMu.Lock()
+	defer m.connectMu.Unlock()
+
+	if driver := m.Config.Driver; config.Driver != driver {
+		return fmt.Errorf("builder: the driver changed from %q to %q, restart to change it", driver, config.Driver)
+	}
+
+	var hosts []*hostDB
+	if !m.external {
+		hosts = m.detachHosts()
+	}
+
+	m.stateMu.Lock()
+	if m.retryPolicy != nil {
+		config.Retry = *m.retryPolicy
+	}
+	m.Config = config
+	m.stateMu.Unlock()
+	m.Grammar.SetTablePrefix(config.Prefix)
+
+	go closeHosts(hosts)
+	return nil
+}
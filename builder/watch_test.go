@@ -0,0 +1,195 @@
+package builder_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/qclaogui/database/builder"
+)
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "database.yml")
+	write := func(yml string) {
+		if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`
+default: sqlite
+sqlite:
+  driver: sqlite3
+  database: /tmp/gogogo.sqlite
+mysql:
+  driver: mysql
+  host: localhost
+  database: gogogo
+  username: root
+`)
+
+	DB, DM := builder.Run(path)
+	MysqlDB := DM.Connection("mysql")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := DM.Watch(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an invalid config keeps the running connections
+	write(`
+default: sqlite
+sqlite:
+  driver: sqlite
+  database: /tmp/gogogo.sqlite
+`)
+	if e := <-events; e.Action != builder.ReloadFailed || e.Connection != "sqlite" || e.Err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: a failed reload of sqlite", e)
+	}
+
+	write(`
+default: sqlite
+sqlite:
+  driver: sqlite3
+  database: /tmp/gogogo.sqlite
+  prefix: go_
+mysql:
+  driver: mysql
+  host: localhost
+  database: gogogo
+  username: root
+`)
+	if e := <-events; e.Action != builder.ReloadRebuilt || e.Connection != "sqlite" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: sqlite rebuilt", e)
+	}
+	// the connection is reconfigured in place, the old handle sees the prefix
+	if DM.Connection("sqlite") != DB {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the changed connection was replaced")
+	}
+	if sql, _, err := DB.Table("users").ToSQL(); err != nil || !strings.Contains(sql, "`go_users`") {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %q, %v want: the go_ prefix", sql, err)
+	}
+	if DM.Connection("mysql") != MysqlDB {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the unchanged connection was rebuilt")
+	}
+
+	// a file read while it is saved keeps the running connections
+	write("")
+	if e := <-events; e.Action != builder.ReloadFailed || e.Err == nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: a failed reload", e)
+	}
+	if DM.Connection("sqlite") != DB {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m the connection was removed by an empty file")
+	}
+
+	// a removed connection is not closed, its handle still works
+	write(`
+default: mysql
+mysql:
+  driver: mysql
+  host: localhost
+  database: gogogo
+  username: root
+`)
+	for _, want := range []builder.ReloadEvent{{Connection: "sqlite", Action: builder.ReloadRemoved}, {Connection: "mysql", Action: builder.ReloadDefault}} {
+		if e := <-events; e.Action != want.Action || e.Connection != want.Connection {
+			t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: %+v", e, want)
+		}
+	}
+	if err = DB.Ping(ctx); err != nil {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m %v", err)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestWatchPasswordFile(t *testing.T) {
+	// the pools are opened by the mysql driver of the config
+	builder.RegisterDriver("mysql", "sqlmock")
+	defer builder.RegisterDriver("mysql", "mysql")
+
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "mysql-password")
+	rotate := func(password string) {
+		if err := os.WriteFile(secret, []byte(password), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	rotate("s3cret")
+
+	path := filepath.Join(dir, "database.yml")
+	yml := `
+default: mysql
+mysql:
+  driver: mysql
+  host: localhost
+  database: gogogo
+  username: root
+  password_file: ` + secret + `
+`
+	if err := os.WriteFile(path, []byte(yml), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	DB, DM := builder.Run(path)
+	defer DM.Close()
+	conn := DB.(*builder.MysqlConnection)
+
+	var queries []string
+	DB.Listen(func(q builder.QueryExecuted) { queries = append(queries, q.SQL) })
+
+	expect := func(password string) sqlmock.Sqlmock {
+		if conn.Config.Password != password {
+			t.Fatalf("\x1b[91mOops🔥\x1b[39m got: %q want: %q", conn.Config.Password, password)
+		}
+		db, mock, err := sqlmock.NewWithDSN(builder.GetDsn(conn.Config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { db.Close() })
+		mock.ExpectQuery("select \\* from `users`").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1"))
+		return mock
+	}
+
+	mock := expect("s3cret")
+	DB.Table("users").Get()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := DM.Watch(ctx, 10*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the yml file is unchanged, the rotated secret is picked up
+	rotate("n3w")
+	if e := <-events; e.Action != builder.ReloadRebuilt || e.Connection != "mysql" {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %+v want: mysql rebuilt", e)
+	}
+
+	// the old handle dials the new DSN and keeps its listeners
+	rotated := expect("n3w")
+	DB.Table("users").Get()
+	if len(queries) != 2 {
+		t.Errorf("\x1b[91mOops🔥\x1b[39m got: %v want: 2 queries", queries)
+	}
+
+	for _, m := range []sqlmock.Sqlmock{mock, rotated} {
+		if err = m.ExpectationsWereMet(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	cancel()
+	for range events {
+	}
+}